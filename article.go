@@ -0,0 +1,486 @@
+package primp
+
+import (
+	"bytes"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Article 表示从页面中提取的正文内容
+type Article struct {
+	Title     string
+	Byline    string
+	Published time.Time
+	LeadImage string
+	HTML      string
+	Markdown  string
+	Text      string
+}
+
+var (
+	// unlikelyCandidates 匹配通常不属于正文的 class 或 id
+	unlikelyCandidates = regexp.MustCompile(`(?i)-ad-|ai2html|banner|breadcrumbs|combx|comment|community|cover-wrap|disqus|extra|footer|gdpr|header|legends|menu|related|remark|replies|rss|shoutbox|sidebar|skyscraper|social|sponsor|supplemental|ad-break|agegate|pagination|pager|popup|yom-remote`)
+	// maybeCandidates 匹配可能包含正文的 class 或 id，优先于 unlikelyCandidates
+	maybeCandidates = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+	// positiveNames 匹配正文容器常用的 class 或 id
+	positiveNames = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|pagination|post|text|blog|story`)
+	// negativeNames 匹配非正文容器常用的 class 或 id
+	negativeNames = regexp.MustCompile(`(?i)-ad-|hidden|^hid$| hid$| hid |^hid |banner|combx|comment|com-|contact|foot|footer|footnote|gdpr|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|widget`)
+	// bylineNames 匹配作者信息的 class、id 或 rel
+	bylineNames = regexp.MustCompile(`(?i)byline|author|dateline|writtenby|p-author`)
+	// titleSeparators 匹配标题中站点名称前的分隔符
+	titleSeparators = regexp.MustCompile(`\s+[|\-–—\\/>»]\s+`)
+)
+
+// articleRemovedElements 是正文中需要去除的元素
+var articleRemovedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Iframe: true, atom.Object: true, atom.Embed: true, atom.Form: true,
+	atom.Nav: true, atom.Aside: true, atom.Footer: true, atom.Button: true,
+	atom.Input: true, atom.Select: true, atom.Textarea: true, atom.Link: true, atom.Meta: true,
+}
+
+// publishedDateLayouts 是常见的发布时间格式
+var publishedDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+	"January 2, 2006",
+	"Jan 2, 2006",
+}
+
+// Article 使用 Readability 风格的评分算法提取页面的正文
+func (r *Response) Article() (*Article, error) {
+	doc, err := r.document()
+	if err != nil {
+		return nil, err
+	}
+
+	return extractArticle(doc, r.URL), nil
+}
+
+// articleExtractor 保存一次正文提取过程中的节点评分
+type articleExtractor struct {
+	base       *url.URL
+	scores     map[*html.Node]float64
+	candidates []*html.Node
+}
+
+// extractArticle 从文档树中提取正文，原文档不会被修改
+func extractArticle(doc *html.Node, baseURL string) *Article {
+	e := &articleExtractor{
+		base:   documentBaseURL(doc, baseURL),
+		scores: make(map[*html.Node]float64),
+	}
+
+	article := &Article{
+		Title:     articleTitle(doc),
+		Byline:    articleByline(doc),
+		Published: articlePublished(doc),
+	}
+	if image := metaContent(doc, "og:image", "twitter:image"); image != "" {
+		article.LeadImage = resolveURL(e.base, image)
+	}
+
+	content := e.content(doc)
+	if content == nil {
+		return article
+	}
+	if article.LeadImage == "" {
+		if img := findElement(content, atom.Img); img != nil {
+			article.LeadImage, _ = getAttr(img, "src")
+		}
+	}
+
+	var buf bytes.Buffer
+	html.Render(&buf, content)
+	article.HTML = buf.String()
+
+	base := ""
+	if e.base != nil {
+		base = e.base.String()
+	}
+	article.Markdown = htmlToMarkdown(content, base)
+	article.Text = htmlToPlainText(content)
+	return article
+}
+
+// content 找到得分最高的候选节点，合并相关的兄弟节点并返回清理后的副本
+func (e *articleExtractor) content(doc *html.Node) *html.Node {
+	body := findElement(doc, atom.Body)
+	if body == nil {
+		return nil
+	}
+	e.score(body)
+
+	var top *html.Node
+	for _, node := range e.candidates {
+		e.scores[node] *= 1 - linkDensity(node)
+		if top == nil || e.scores[node] > e.scores[top] {
+			top = node
+		}
+	}
+	if top == nil {
+		top = body
+	}
+
+	// 得分较高的兄弟节点和文字较多的段落同样属于正文
+	root := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	if top == body {
+		for child := body.FirstChild; child != nil; child = child.NextSibling {
+			if cleaned := e.clean(child); cleaned != nil {
+				root.AppendChild(cleaned)
+			}
+		}
+		return root
+	}
+	threshold := max(10, e.scores[top]*0.2)
+	for sibling := top.Parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+		include := sibling == top
+		if !include && sibling.Type == html.ElementNode {
+			if score, ok := e.scores[sibling]; ok && score >= threshold {
+				include = true
+			} else if sibling.DataAtom == atom.P {
+				text := innerText(sibling)
+				density := linkDensity(sibling)
+				include = (len(text) > 80 && density < 0.25) || (len(text) > 0 && density == 0 && strings.Contains(text, ". "))
+			}
+		}
+		if include {
+			if cleaned := e.clean(sibling); cleaned != nil {
+				root.AppendChild(cleaned)
+			}
+		}
+	}
+	return root
+}
+
+// score 为段落类元素打分，并将得分传递给父节点和祖父节点
+func (e *articleExtractor) score(n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || e.unlikely(child) {
+			continue
+		}
+
+		switch child.DataAtom {
+		case atom.P, atom.Pre, atom.Td:
+			e.scoreParagraph(child)
+		case atom.Div, atom.Section:
+			// 只包含行内内容的 div 按段落处理
+			if !hasBlockChild(child) {
+				e.scoreParagraph(child)
+				continue
+			}
+		}
+		e.score(child)
+	}
+}
+
+// scoreParagraph 根据文字长度和逗号数量为段落打分
+func (e *articleExtractor) scoreParagraph(n *html.Node) {
+	text := innerText(n)
+	if len([]rune(text)) < 25 {
+		return
+	}
+
+	score := 1.0
+	score += float64(strings.Count(text, ",") + strings.Count(text, "，"))
+	score += min(float64(len([]rune(text))/100), 3)
+
+	ancestor := n.Parent
+	for level := 0; level < 3 && ancestor != nil && ancestor.Type == html.ElementNode; level++ {
+		if _, ok := e.scores[ancestor]; !ok {
+			e.scores[ancestor] = initialScore(ancestor)
+			e.candidates = append(e.candidates, ancestor)
+		}
+		divider := 1.0
+		if level == 1 {
+			divider = 2
+		} else if level > 1 {
+			divider = float64(level * 3)
+		}
+		e.scores[ancestor] += score / divider
+		ancestor = ancestor.Parent
+	}
+}
+
+// unlikely 判断元素是否不可能是正文
+func (e *articleExtractor) unlikely(n *html.Node) bool {
+	if articleRemovedElements[n.DataAtom] || n.DataAtom == atom.Header || isHiddenElement(n) {
+		return true
+	}
+	names := classAndID(n)
+	if role, _ := getAttr(n, "role"); role == "navigation" || role == "complementary" || role == "banner" || role == "dialog" {
+		return true
+	}
+	return unlikelyCandidates.MatchString(names) && !maybeCandidates.MatchString(names) &&
+		n.DataAtom != atom.Body && n.DataAtom != atom.A && n.DataAtom != atom.Table
+}
+
+// clean 返回去除了无关元素、链接已解析为绝对地址的节点副本
+func (e *articleExtractor) clean(n *html.Node) *html.Node {
+	switch n.Type {
+	case html.TextNode:
+		return &html.Node{Type: html.TextNode, Data: n.Data}
+	case html.ElementNode:
+	default:
+		return nil
+	}
+	if e.unlikely(n) {
+		return nil
+	}
+	// 链接密度过高且文字较少的容器通常是导航或推荐列表
+	if n.DataAtom == atom.Div || n.DataAtom == atom.Ul || n.DataAtom == atom.Section {
+		if linkDensity(n) > 0.5 && len(innerText(n)) < 200 {
+			return nil
+		}
+		if negativeNames.MatchString(classAndID(n)) && e.scores[n] < 0 {
+			return nil
+		}
+	}
+
+	clone := &html.Node{Type: n.Type, Data: n.Data, DataAtom: n.DataAtom, Namespace: n.Namespace}
+	for _, attr := range n.Attr {
+		key := strings.ToLower(attr.Key)
+		// 事件处理属性和脚本地址不属于正文，也不能出现在输出的 HTML 中
+		if strings.HasPrefix(key, "on") {
+			continue
+		}
+		switch key {
+		case "href", "src", "poster":
+			if unsafeURL(attr.Val) {
+				continue
+			}
+			attr.Val = resolveURL(e.base, attr.Val)
+		case "srcset":
+			if unsafeURL(attr.Val) {
+				continue
+			}
+		case "style", "class", "id":
+			continue
+		}
+		clone.Attr = append(clone.Attr, attr)
+	}
+	// 地址被去掉的图片无法显示，也不能作为题图
+	if n.DataAtom == atom.Img {
+		if _, ok := getAttr(clone, "src"); !ok {
+			return nil
+		}
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if cleaned := e.clean(child); cleaned != nil {
+			clone.AppendChild(cleaned)
+		}
+	}
+	return clone
+}
+
+// unsafeURL 判断地址是否使用可以执行脚本或内嵌内容的 javascript:、vbscript: 或 data: 协议
+// 浏览器会忽略协议名中的空白和控制字符，因此先去掉这些字符再比较
+func unsafeURL(value string) bool {
+	scheme := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, value)
+	scheme = strings.ToLower(scheme)
+	return strings.HasPrefix(scheme, "javascript:") || strings.HasPrefix(scheme, "vbscript:") || strings.HasPrefix(scheme, "data:")
+}
+
+// initialScore 根据标签类型和 class/id 给出初始得分
+func initialScore(n *html.Node) float64 {
+	score := 0.0
+	switch n.DataAtom {
+	case atom.Div, atom.Article:
+		score = 5
+	case atom.Pre, atom.Td, atom.Blockquote:
+		score = 3
+	case atom.Address, atom.Ol, atom.Ul, atom.Dl, atom.Dd, atom.Dt, atom.Li, atom.Form:
+		score = -3
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Th:
+		score = -5
+	}
+
+	names := classAndID(n)
+	if negativeNames.MatchString(names) {
+		score -= 25
+	}
+	if positiveNames.MatchString(names) {
+		score += 25
+	}
+	return score
+}
+
+// classAndID 返回元素的 class 和 id 拼接结果
+func classAndID(n *html.Node) string {
+	class, _ := getAttr(n, "class")
+	id, _ := getAttr(n, "id")
+	return strings.TrimSpace(class + " " + id)
+}
+
+// hasBlockChild 判断元素是否包含块级子元素
+func hasBlockChild(n *html.Node) bool {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if isBlock(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// innerText 返回元素压缩空白后的文本
+func innerText(n *html.Node) string {
+	return strings.TrimSpace(collapseWhitespace(textContent(n)))
+}
+
+// linkDensity 返回元素中链接文本占全部文本的比例
+func linkDensity(n *html.Node) float64 {
+	total := len(innerText(n))
+	if total == 0 {
+		return 0
+	}
+
+	linkLength := 0
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode && node.DataAtom == atom.A {
+			linkLength += len(innerText(node))
+			return
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return float64(linkLength) / float64(total)
+}
+
+// metaContent 返回第一个匹配 property 或 name 的 meta 标签内容
+func metaContent(doc *html.Node, keys ...string) string {
+	values := make(map[string]string)
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Meta {
+			content, _ := getAttr(n, "content")
+			for _, attr := range []string{"property", "name", "itemprop"} {
+				if key, ok := getAttr(n, attr); ok {
+					key = strings.ToLower(strings.TrimSpace(key))
+					if _, exists := values[key]; !exists {
+						values[key] = strings.TrimSpace(content)
+					}
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+
+	for _, key := range keys {
+		if value := values[key]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// articleTitle 返回文章标题，优先使用 OpenGraph 标题，其次是去掉站点名称的 <title>
+func articleTitle(doc *html.Node) string {
+	if title := metaContent(doc, "og:title", "twitter:title"); title != "" {
+		return title
+	}
+
+	if n := findElement(doc, atom.Title); n != nil {
+		title := innerText(n)
+		if loc := titleSeparators.FindAllStringIndex(title, -1); len(loc) > 0 {
+			// 站点名称通常在最后一个分隔符之后
+			if candidate := title[:loc[len(loc)-1][0]]; len(strings.Fields(candidate)) >= 3 {
+				return candidate
+			}
+		}
+		if title != "" {
+			return title
+		}
+	}
+
+	if n := findElement(doc, atom.H1); n != nil {
+		return innerText(n)
+	}
+	return ""
+}
+
+// articleByline 返回文章作者
+func articleByline(doc *html.Node) string {
+	if author := metaContent(doc, "author", "article:author", "dc.creator", "twitter:creator"); author != "" && !strings.HasPrefix(author, "http") {
+		return author
+	}
+
+	var byline string
+	var walk func(*html.Node) bool
+	walk = func(n *html.Node) bool {
+		if n.Type == html.ElementNode && !isHiddenElement(n) {
+			rel, _ := getAttr(n, "rel")
+			itemprop, _ := getAttr(n, "itemprop")
+			if rel == "author" || strings.Contains(itemprop, "author") || bylineNames.MatchString(classAndID(n)) {
+				if text := innerText(n); text != "" && len([]rune(text)) < 100 {
+					byline = text
+					return true
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if walk(child) {
+				return true
+			}
+		}
+		return false
+	}
+	walk(doc)
+	return byline
+}
+
+// articlePublished 从 meta 标签、itemprop 或 <time> 元素中解析发布时间
+func articlePublished(doc *html.Node) time.Time {
+	candidates := []string{metaContent(doc, "article:published_time", "datepublished", "og:published_time",
+		"pubdate", "publishdate", "date", "dc.date", "dc.date.issued", "sailthru.date")}
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			itemprop, _ := getAttr(n, "itemprop")
+			if n.DataAtom == atom.Time || itemprop == "datePublished" {
+				if value, ok := getAttr(n, "datetime"); ok {
+					candidates = append(candidates, value)
+				} else if value, ok := getAttr(n, "content"); ok {
+					candidates = append(candidates, value)
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+
+	for _, candidate := range candidates {
+		candidate = strings.TrimSpace(candidate)
+		for _, layout := range publishedDateLayouts {
+			if t, err := time.Parse(layout, candidate); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
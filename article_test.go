@@ -0,0 +1,197 @@
+package primp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// articleParagraph 返回一段足够长、带逗号的正文段落
+func articleParagraph(topic string) string {
+	return "<p>" + topic + " is discussed here at length, with enough words, commas, and detail " +
+		"that the readability scorer treats it as real prose rather than navigation, chrome, or boilerplate.</p>"
+}
+
+func TestArticleScoring(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "longest container wins",
+			body: `<div class="teaser"><p>A short teaser that nobody should pick, really.</p></div>` +
+				`<div>` + articleParagraph("Alpha") + articleParagraph("Beta") + articleParagraph("Gamma") + `</div>`,
+			want:    []string{"Alpha is discussed", "Beta is discussed", "Gamma is discussed"},
+			notWant: []string{"short teaser"},
+		},
+		{
+			name: "positive class beats negative class",
+			body: `<div class="sidebar-widget">` + articleParagraph("Widget") + articleParagraph("Promo") + `</div>` +
+				`<div class="post-content">` + articleParagraph("Story") + `</div>`,
+			want:    []string{"Story is discussed"},
+			notWant: []string{"Widget is discussed", "Promo is discussed"},
+		},
+		{
+			name: "link heavy container loses",
+			body: `<div><p><a href="/a">Linked words, linked words, linked words, linked words, linked words</a></p>` +
+				`<p><a href="/b">More linked words, more linked words, more linked words, more linked words</a></p></div>` +
+				`<div>` + articleParagraph("Plain") + `</div>`,
+			want:    []string{"Plain is discussed"},
+			notWant: []string{"Linked words"},
+		},
+		{
+			name: "related paragraph sibling is merged",
+			body: `<div><div class="entry">` + articleParagraph("Main") + articleParagraph("Second") + `</div>` +
+				`<p>A closing remark without links. It belongs to the article even outside the container.</p></div>`,
+			want: []string{"Main is discussed", "Second is discussed", "closing remark"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader("<html><body>" + tt.body + "</body></html>"))
+			if err != nil {
+				t.Fatalf("html.Parse() error = %v", err)
+			}
+			article := extractArticle(doc, "https://example.com/post")
+			for _, want := range tt.want {
+				if !strings.Contains(article.Text, want) {
+					t.Errorf("Text = %q, want it to contain %q", article.Text, want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(article.Text, notWant) {
+					t.Errorf("Text = %q, want it not to contain %q", article.Text, notWant)
+				}
+			}
+		})
+	}
+}
+
+func TestArticleRemovesBoilerplate(t *testing.T) {
+	page := `<!DOCTYPE html><html><head>
+<title>Readable Go Clients Explained | Example Blog</title>
+<meta name="author" content="Jane Doe">
+<meta property="article:published_time" content="2024-03-05T10:00:00Z">
+<script>var tracking = "SCRIPT_MARKER";</script>
+</head><body>
+<header><a href="/">HEADER_MARKER</a></header>
+<nav><a href="/a">NAV_MARKER one</a> <a href="/b">two</a></nav>
+<div id="sidebar"><p>SIDEBAR_MARKER with enough text, commas, and words to be scored as a paragraph.</p></div>
+<article>
+<h1>Readable Go Clients Explained</h1>
+` + articleParagraph("Connection pooling") + `
+<p>See the <a href="/docs/pooling">pooling guide</a> for more, with commas, clauses, and enough text to count.</p>
+<img src="/images/diagram.png" class="figure" style="width:100%">
+<div class="share"><a href="/s/1">SHARE_MARKER</a> <a href="/s/2">tweet</a></div>
+<form><input name="email"><button>FORM_MARKER</button></form>
+` + articleParagraph("Timeouts") + `
+<p style="display:none">HIDDEN_MARKER with enough words, commas, and more words to be scored anyway.</p>
+</article>
+<aside>ASIDE_MARKER</aside>
+<div class="comments"><p>COMMENT_MARKER: great post, thanks, very helpful, would read again.</p></div>
+<footer>FOOTER_MARKER</footer>
+</body></html>`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	resp, err := NewClient().Get(server.URL + "/posts/1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	article, err := resp.Article()
+	if err != nil {
+		t.Fatalf("Article() error = %v", err)
+	}
+
+	if article.Title != "Readable Go Clients Explained" {
+		t.Errorf("Title = %q, want %q", article.Title, "Readable Go Clients Explained")
+	}
+	if article.Byline != "Jane Doe" {
+		t.Errorf("Byline = %q, want %q", article.Byline, "Jane Doe")
+	}
+	if want := time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC); !article.Published.Equal(want) {
+		t.Errorf("Published = %v, want %v", article.Published, want)
+	}
+	if want := server.URL + "/images/diagram.png"; article.LeadImage != want {
+		t.Errorf("LeadImage = %q, want %q", article.LeadImage, want)
+	}
+
+	for _, want := range []string{"Connection pooling is discussed", "pooling guide", "Timeouts is discussed"} {
+		if !strings.Contains(article.Text, want) {
+			t.Errorf("Text = %q, want it to contain %q", article.Text, want)
+		}
+	}
+	for _, marker := range []string{"SCRIPT_MARKER", "HEADER_MARKER", "NAV_MARKER", "SIDEBAR_MARKER", "SHARE_MARKER",
+		"FORM_MARKER", "HIDDEN_MARKER", "ASIDE_MARKER", "COMMENT_MARKER", "FOOTER_MARKER"} {
+		if strings.Contains(article.HTML, marker) {
+			t.Errorf("HTML contains boilerplate %q: %s", marker, article.HTML)
+		}
+	}
+
+	// 链接解析为绝对地址，样式和 class 属性被去除
+	if want := `href="` + server.URL + `/docs/pooling"`; !strings.Contains(article.HTML, want) {
+		t.Errorf("HTML = %s, want it to contain %s", article.HTML, want)
+	}
+	if strings.Contains(article.HTML, "style=") || strings.Contains(article.HTML, "class=") {
+		t.Errorf("HTML = %s, want style and class attributes removed", article.HTML)
+	}
+	if !strings.Contains(article.Markdown, "[pooling guide]("+server.URL+"/docs/pooling)") {
+		t.Errorf("Markdown = %q, want an absolute pooling guide link", article.Markdown)
+	}
+}
+
+func TestArticleEmptyBody(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><head><title>Only A Title Here</title></head><body></body></html>`))
+	if err != nil {
+		t.Fatalf("html.Parse() error = %v", err)
+	}
+	article := extractArticle(doc, "https://example.com/")
+	if article.Title != "Only A Title Here" {
+		t.Errorf("Title = %q, want %q", article.Title, "Only A Title Here")
+	}
+	if article.Text != "" {
+		t.Errorf("Text = %q, want empty", article.Text)
+	}
+}
+
+func TestArticleRemovesScriptAttributes(t *testing.T) {
+	page := `<html><body><article>` + articleParagraph("Safety") + `
+<p onclick="steal()" ONMOUSEOVER="steal()">Handlers on a paragraph, with commas, and enough words to be kept.</p>
+<p><a href="javascript:steal()">js link</a> <a href=" JaVa&#x09;Script:steal()">obfuscated link</a>
+<a href="vbscript:msgbox(1)">vb link</a> <a href="/safe">safe link</a>, with commas, and enough words.</p>
+<img src="data:text/html;base64,PHNjcmlwdD4=" onerror="steal()" alt="data image">
+<img src="/real.png" srcset="data:image/png;base64,AAAA 2x" onload="steal()" alt="real image">
+<svg><a xlink:href="javascript:steal()"><text>svg link</text></a></svg>
+</article></body></html>`
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("html.Parse() error = %v", err)
+	}
+	article := extractArticle(doc, "https://example.com/post")
+
+	lower := strings.ToLower(article.HTML)
+	for _, bad := range []string{"steal", "javascript", "vbscript", "data:", "onclick", "onmouseover", "onerror", "onload"} {
+		if strings.Contains(lower, bad) {
+			t.Errorf("HTML contains %q: %s", bad, article.HTML)
+		}
+	}
+	for _, want := range []string{`href="https://example.com/safe"`, `src="https://example.com/real.png"`, "js link", "Handlers on a paragraph"} {
+		if !strings.Contains(article.HTML, want) {
+			t.Errorf("HTML = %s, want it to contain %s", article.HTML, want)
+		}
+	}
+	if article.LeadImage != "https://example.com/real.png" {
+		t.Errorf("LeadImage = %q, want the first safe image", article.LeadImage)
+	}
+}
@@ -0,0 +1,153 @@
+package primp
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ChallengeProvider 表示反爬虫服务提供商
+type ChallengeProvider string
+
+// 支持识别的反爬虫服务
+const (
+	Cloudflare ChallengeProvider = "cloudflare"
+	Akamai     ChallengeProvider = "akamai"
+	DataDome   ChallengeProvider = "datadome"
+	PerimeterX ChallengeProvider = "perimeterx"
+	HCaptcha   ChallengeProvider = "hcaptcha"
+)
+
+// ChallengeType 表示验证页面的类型
+type ChallengeType string
+
+const (
+	// ChallengeJS 表示需要执行 JavaScript 的验证页面
+	ChallengeJS ChallengeType = "js"
+	// ChallengeCaptcha 表示需要人工完成的验证码
+	ChallengeCaptcha ChallengeType = "captcha"
+	// ChallengeBlock 表示请求被直接拒绝
+	ChallengeBlock ChallengeType = "block"
+)
+
+// Challenge 表示识别出的反爬虫验证页面
+type Challenge struct {
+	Provider ChallengeProvider
+	Type     ChallengeType
+	// Signal 描述命中的特征，便于排查误判
+	Signal string
+}
+
+// challengeSignature 是一条识别规则，所有非空条件都满足时命中
+type challengeSignature struct {
+	provider ChallengeProvider
+	typ      ChallengeType
+	// blocked 要求状态码为 403、429 或 503
+	blocked bool
+	header  string
+	value   string
+	cookie  string
+	body    string
+}
+
+// challengeSignatures 按优先级排列，更具体的规则在前
+// 例如 PerimeterX 和 DataDome 的验证码页面会嵌入 hCaptcha，因此 hCaptcha 放在最后
+var challengeSignatures = []challengeSignature{
+	{provider: Cloudflare, typ: ChallengeJS, header: "Cf-Mitigated", value: "challenge"},
+	{provider: Cloudflare, typ: ChallengeCaptcha, blocked: true, header: "Server", value: "cloudflare", body: "cf-turnstile"},
+	{provider: Cloudflare, typ: ChallengeJS, body: "window._cf_chl_opt"},
+	{provider: Cloudflare, typ: ChallengeJS, blocked: true, body: "/cdn-cgi/challenge-platform/"},
+	{provider: Cloudflare, typ: ChallengeBlock, blocked: true, header: "Server", value: "cloudflare", body: "cf-error-details"},
+
+	{provider: DataDome, typ: ChallengeCaptcha, body: "geo.captcha-delivery.com"},
+	{provider: DataDome, typ: ChallengeCaptcha, blocked: true, header: "X-DataDome", value: "protected"},
+	{provider: DataDome, typ: ChallengeBlock, blocked: true, header: "X-DD-B"},
+	{provider: DataDome, typ: ChallengeBlock, blocked: true, cookie: "datadome"},
+
+	{provider: PerimeterX, typ: ChallengeCaptcha, body: "captcha.px-cdn.net"},
+	{provider: PerimeterX, typ: ChallengeCaptcha, body: "_pxcaptcha"},
+	{provider: PerimeterX, typ: ChallengeCaptcha, blocked: true, body: "px-captcha"},
+	{provider: PerimeterX, typ: ChallengeBlock, blocked: true, cookie: "_pxhd"},
+
+	{provider: Akamai, typ: ChallengeJS, body: "/_sec/cp_challenge/"},
+	{provider: Akamai, typ: ChallengeJS, blocked: true, body: "bm-verify"},
+	{provider: Akamai, typ: ChallengeBlock, blocked: true, header: "Server", value: "akamaighost", body: "reference #"},
+	// Akamai 的拒绝页面以 HTML 实体输出 "Reference #"
+	{provider: Akamai, typ: ChallengeBlock, blocked: true, header: "Server", value: "akamaighost", body: "reference&#32;&#35;"},
+	{provider: Akamai, typ: ChallengeBlock, blocked: true, cookie: "_abck", body: "access denied"},
+
+	{provider: HCaptcha, typ: ChallengeCaptcha, body: "hcaptcha.com/1/api.js"},
+	{provider: HCaptcha, typ: ChallengeCaptcha, blocked: true, body: "h-captcha"},
+}
+
+// Challenge 根据状态码、响应头、cookies 和页面特征识别反爬虫验证页面，未识别时返回 nil
+// 流模式下只检查状态码、响应头和 cookies
+func (r *Response) Challenge() *Challenge {
+	var cookieNames map[string]bool
+	for _, cookie := range r.httpResp.Cookies() {
+		if cookieNames == nil {
+			cookieNames = make(map[string]bool)
+		}
+		cookieNames[strings.ToLower(cookie.Name)] = true
+	}
+
+	// 与 RaiseForStatus 相同，只在响应体已缓冲时检查页面内容
+	var body string
+	if r.raw != nil || r.content != nil {
+		if text, err := r.Text(); err == nil {
+			body = strings.ToLower(text)
+		}
+	}
+
+	return detectChallenge(r.StatusCode, r.httpResp.Header, cookieNames, body)
+}
+
+// detectChallenge 依次匹配识别规则，body 需为小写
+func detectChallenge(statusCode int, header http.Header, cookies map[string]bool, body string) *Challenge {
+	blocked := statusCode == http.StatusForbidden || statusCode == http.StatusTooManyRequests ||
+		statusCode == http.StatusServiceUnavailable
+
+	for _, sig := range challengeSignatures {
+		if sig.blocked && !blocked {
+			continue
+		}
+		if sig.header != "" {
+			values := header.Values(sig.header)
+			if len(values) == 0 {
+				continue
+			}
+			if sig.value != "" && !strings.Contains(strings.ToLower(strings.Join(values, ",")), sig.value) {
+				continue
+			}
+		}
+		if sig.cookie != "" && !cookies[sig.cookie] {
+			continue
+		}
+		if sig.body != "" && !strings.Contains(body, sig.body) {
+			continue
+		}
+		return &Challenge{Provider: sig.provider, Type: sig.typ, Signal: sig.describe()}
+	}
+	return nil
+}
+
+// describe 返回规则的可读描述
+func (sig challengeSignature) describe() string {
+	var parts []string
+	if sig.blocked {
+		parts = append(parts, "blocking status")
+	}
+	if sig.header != "" {
+		if sig.value != "" {
+			parts = append(parts, "header "+sig.header+": "+sig.value)
+		} else {
+			parts = append(parts, "header "+sig.header)
+		}
+	}
+	if sig.cookie != "" {
+		parts = append(parts, "cookie "+sig.cookie)
+	}
+	if sig.body != "" {
+		parts = append(parts, "body contains "+sig.body)
+	}
+	return strings.Join(parts, ", ")
+}
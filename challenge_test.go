@@ -0,0 +1,72 @@
+package primp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// challengeFixture 是录制的响应及期望的识别结果，want 为 null 表示不应识别为验证页面
+type challengeFixture struct {
+	Status  int                 `json:"status"`
+	Headers map[string][]string `json:"headers"`
+	Body    string              `json:"body"`
+	Want    *struct {
+		Provider ChallengeProvider `json:"provider"`
+		Type     ChallengeType     `json:"type"`
+	} `json:"want"`
+}
+
+func TestChallengeFixtures(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "challenge", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no challenge fixtures found")
+	}
+
+	for _, path := range paths {
+		t.Run(strings.TrimSuffix(filepath.Base(path), ".json"), func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fixture challengeFixture
+			if err := json.Unmarshal(data, &fixture); err != nil {
+				t.Fatalf("invalid fixture: %v", err)
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for name, values := range fixture.Headers {
+					for _, value := range values {
+						w.Header().Add(name, value)
+					}
+				}
+				w.WriteHeader(fixture.Status)
+				w.Write([]byte(fixture.Body))
+			}))
+			defer server.Close()
+
+			resp, err := NewClient().Get(server.URL)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			defer resp.Close()
+
+			got := resp.Challenge()
+			switch {
+			case fixture.Want == nil && got != nil:
+				t.Errorf("Challenge() = %+v, want nil", got)
+			case fixture.Want != nil && got == nil:
+				t.Errorf("Challenge() = nil, want %s/%s", fixture.Want.Provider, fixture.Want.Type)
+			case fixture.Want != nil && (got.Provider != fixture.Want.Provider || got.Type != fixture.Want.Type):
+				t.Errorf("Challenge() = %s/%s (%s), want %s/%s", got.Provider, got.Type, got.Signal, fixture.Want.Provider, fixture.Want.Type)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package primp
+
+import (
+	"bytes"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/saintfish/chardet"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// prescanLimit 是 WHATWG 规范中 meta 预扫描的字节数
+const prescanLimit = 1024
+
+// xmlEncodingPattern 匹配 XML 声明中的 encoding 属性
+var xmlEncodingPattern = regexp.MustCompile(`^<\?xml\s[^>]*encoding\s*=\s*["']([A-Za-z0-9._:-]+)["']`)
+
+// detectEncoding 按 WHATWG 的顺序确定响应内容的编码：
+// BOM、Content-Type 头部、meta 预扫描、XML 声明，最后使用统计检测
+func detectEncoding(content []byte, contentType string) string {
+	if name := bomEncoding(content); name != "" {
+		return name
+	}
+
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		if name := lookupCharset(params["charset"]); name != "" {
+			return name
+		}
+	}
+
+	head := content
+	if len(head) > prescanLimit {
+		head = head[:prescanLimit]
+	}
+	if name := prescanMeta(head); name != "" {
+		return name
+	}
+	if match := xmlEncodingPattern.FindSubmatch(bytes.TrimLeft(head, " \t\r\n")); match != nil {
+		if name := lookupCharset(string(match[1])); name != "" {
+			return name
+		}
+	}
+
+	return guessEncoding(content)
+}
+
+// bomEncoding 根据字节顺序标记返回编码
+func bomEncoding(content []byte) string {
+	switch {
+	case bytes.HasPrefix(content, []byte{0xEF, 0xBB, 0xBF}):
+		return "utf-8"
+	case bytes.HasPrefix(content, []byte{0xFE, 0xFF}):
+		return "utf-16be"
+	case bytes.HasPrefix(content, []byte{0xFF, 0xFE}):
+		return "utf-16le"
+	}
+	return ""
+}
+
+// lookupCharset 将字符集标签规范化为 WHATWG 编码名称，未知标签返回空字符串
+func lookupCharset(label string) string {
+	label = strings.Trim(strings.TrimSpace(label), `"'`)
+	if label == "" {
+		return ""
+	}
+	if e, name := charset.Lookup(label); e != nil {
+		return name
+	}
+	return ""
+}
+
+// prescanMeta 在 HTML 头部中查找 <meta charset> 或 http-equiv 声明的编码
+func prescanMeta(head []byte) string {
+	z := html.NewTokenizer(bytes.NewReader(head))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if !bytes.Equal(name, []byte("meta")) || !hasAttr {
+				continue
+			}
+
+			var label, httpEquiv, content string
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				switch string(key) {
+				case "charset":
+					label = string(val)
+				case "http-equiv":
+					httpEquiv = strings.ToLower(string(val))
+				case "content":
+					content = string(val)
+				}
+			}
+			if label == "" && httpEquiv == "content-type" {
+				label = charsetFromMetaContent(content)
+			}
+
+			if name := lookupCharset(label); name != "" {
+				// 规范要求 meta 中声明的 UTF-16 按 UTF-8 处理
+				if strings.HasPrefix(name, "utf-16") {
+					return "utf-8"
+				}
+				if name == "x-user-defined" {
+					return "windows-1252"
+				}
+				return name
+			}
+		}
+	}
+}
+
+// charsetFromMetaContent 从 meta content 属性（如 "text/html; charset=gbk"）中提取字符集
+func charsetFromMetaContent(content string) string {
+	lower := strings.ToLower(content)
+	i := strings.Index(lower, "charset")
+	if i < 0 {
+		return ""
+	}
+	rest := strings.TrimLeft(content[i+len("charset"):], " \t")
+	if !strings.HasPrefix(rest, "=") {
+		return ""
+	}
+	rest = strings.TrimLeft(rest[1:], " \t")
+	if end := strings.IndexAny(rest, "; \t"); end >= 0 {
+		rest = rest[:end]
+	}
+	return strings.Trim(rest, `"'`)
+}
+
+// guessEncoding 使用统计检测器猜测编码，无法确定时使用 UTF-8
+func guessEncoding(content []byte) string {
+	if isASCII(content) || utf8.Valid(trimPartialRune(content)) {
+		return "utf-8"
+	}
+
+	result, err := chardet.NewHtmlDetector().DetectBest(content)
+	if err != nil || result.Confidence < 10 {
+		return "windows-1252"
+	}
+
+	label := result.Charset
+	if label == "GB-18030" {
+		label = "gb18030"
+	}
+	if name := lookupCharset(label); name != "" {
+		return name
+	}
+	return "windows-1252"
+}
+
+// isASCII 判断内容是否只包含 ASCII 字符
+func isASCII(content []byte) bool {
+	for _, b := range content {
+		if b >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// trimPartialRune 去掉末尾被截断的多字节字符
+func trimPartialRune(content []byte) []byte {
+	for i := len(content) - 1; i >= 0 && i > len(content)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(content[i]) {
+			if !utf8.FullRune(content[i:]) {
+				return content[:i]
+			}
+			break
+		}
+	}
+	return content
+}
@@ -0,0 +1,149 @@
+package primp
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectEncoding(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     []byte
+		contentType string
+		want        string
+	}{
+		{
+			name:        "utf-8 BOM beats header",
+			content:     append([]byte{0xEF, 0xBB, 0xBF}, "<p>caf\xc3\xa9</p>"...),
+			contentType: "text/html; charset=iso-8859-2",
+			want:        "utf-8",
+		},
+		{
+			name:        "utf-16le BOM beats header and meta",
+			content:     []byte{0xFF, 0xFE, '<', 0, 'p', 0, '>', 0},
+			contentType: "text/html; charset=gbk",
+			want:        "utf-16le",
+		},
+		{
+			name:    "utf-16be BOM",
+			content: []byte{0xFE, 0xFF, 0, '<', 0, 'p', 0, '>'},
+			want:    "utf-16be",
+		},
+		{
+			name:        "header beats meta",
+			content:     []byte(`<meta charset="shift_jis"><p>text</p>`),
+			contentType: "text/html; charset=windows-1251",
+			want:        "windows-1251",
+		},
+		{
+			name:        "header label is normalized",
+			content:     []byte("<p>text</p>"),
+			contentType: `text/html; charset="Latin1"`,
+			want:        "windows-1252",
+		},
+		{
+			name:        "unknown header label falls through to meta",
+			content:     []byte(`<meta charset="gbk"><p>text</p>`),
+			contentType: "text/html; charset=no-such-charset",
+			want:        "gbk",
+		},
+		{
+			name:    "meta charset",
+			content: []byte("<html><head><meta charset='EUC-JP'></head><body>\xa4\xa2</body></html>"),
+			want:    "euc-jp",
+		},
+		{
+			name:    "meta http-equiv",
+			content: []byte(`<html><head><meta http-equiv="Content-Type" content="text/html; charset=GBK"></head><body>` + "\xd6\xd0</body></html>"),
+			want:    "gbk",
+		},
+		{
+			name:    "meta utf-16 is treated as utf-8",
+			content: []byte(`<meta charset="utf-16"><p>caf` + "\xc3\xa9</p>"),
+			want:    "utf-8",
+		},
+		{
+			name:    "meta x-user-defined is treated as windows-1252",
+			content: []byte(`<meta charset="x-user-defined"><p>caf` + "\xe9</p>"),
+			want:    "windows-1252",
+		},
+		{
+			name:    "meta after prescan limit is ignored",
+			content: []byte("<!--" + strings.Repeat("x", prescanLimit) + `--><meta charset="gbk"><p>text</p>`),
+			want:    "utf-8",
+		},
+		{
+			name:    "xml declaration",
+			content: []byte("  <?xml version=\"1.0\" encoding='ISO-8859-2'?><root>\xb1</root>"),
+			want:    "iso-8859-2",
+		},
+		{
+			name:    "valid utf-8 without declaration",
+			content: []byte("<p>\xe4\xb8\xad\xe6\x96\x87</p>"),
+			want:    "utf-8",
+		},
+		{
+			name:    "ascii without declaration",
+			content: []byte("<p>plain</p>"),
+			want:    "utf-8",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectEncoding(tt.content, tt.contentType); got != tt.want {
+				t.Errorf("detectEncoding() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectEncodingFallback(t *testing.T) {
+	tests := []struct {
+		file string
+		want string
+		text string
+	}{
+		{"gbk.html", "gb18030", "中文网页的编码检测"},
+		{"shift_jis.html", "shift_jis", "日本語のウェブページ"},
+		{"windows-1251.html", "windows-1251", "Определение кодировки"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			content, err := os.ReadFile(filepath.Join("testdata", "charset", tt.file))
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+			if bytes.Contains(content, []byte(tt.text)) {
+				t.Fatalf("fixture %s is UTF-8, want legacy encoded bytes", tt.file)
+			}
+			if got := detectEncoding(content, "text/html"); got != tt.want {
+				t.Errorf("detectEncoding() = %q, want %q", got, tt.want)
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				w.Write(content)
+			}))
+			defer server.Close()
+
+			resp, err := NewClient().Get(server.URL)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			text, err := resp.Text()
+			if err != nil || !strings.Contains(text, tt.text) {
+				t.Errorf("Text() = %q, %v; want it to contain %q", text, err, tt.text)
+			}
+			if resp.Encoding() != tt.want {
+				t.Errorf("Encoding() = %q, want %q", resp.Encoding(), tt.want)
+			}
+		})
+	}
+}
@@ -7,12 +7,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"mime/multipart"
+	"log/slog"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
 	"os"
-	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -26,6 +26,12 @@ type Client struct {
 	auth          *BasicAuth
 	authBearer    string
 	params        map[string]string
+	paramsPolicy  ParamsPolicy
+	middlewares   []Middleware
+	logger        *slog.Logger
+	telemetry     *telemetry
+	harRecorder   *HARRecorder
+	replayer      *Replayer
 	proxy         string
 	timeout       time.Duration
 	impersonate   Impersonate
@@ -36,6 +42,7 @@ type Client struct {
 	caCertFile    string
 	httpsOnly     bool
 	http2Only     bool
+	maxBodySize   int64
 }
 
 // NewClient 创建一个新的带有给定选项的 HTTP 客户端
@@ -48,8 +55,8 @@ func NewClient(options ...Option) *Client {
 	// 默认客户端
 	client := &Client{
 		httpClient: &http.Client{
-			Jar:     jar,
-			Timeout: 30 * time.Second,
+			Jar:           jar,
+			CheckRedirect: checkRedirect,
 		},
 		headers:     make(map[string]string),
 		cookieStore: true,
@@ -75,10 +82,7 @@ func NewClient(options ...Option) *Client {
 	if client.proxy != "" {
 		proxyURL, err := url.Parse(client.proxy)
 		if err == nil {
-			transport := &http.Transport{
-				Proxy: http.ProxyURL(proxyURL),
-			}
-			client.httpClient.Transport = transport
+			client.transport().Proxy = http.ProxyURL(proxyURL)
 		}
 	}
 
@@ -95,10 +99,7 @@ func NewClient(options ...Option) *Client {
 		}
 	} else {
 		// 如果 verify 为 false，则跳过 SSL 验证
-		transport := &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
-		client.httpClient.Transport = transport
+		client.transport().TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
 	}
 
 	return client
@@ -116,13 +117,21 @@ func (c *Client) applyBrowserImpersonation() {
 func (c *Client) applyCACertificate() {
 	certPool, err := LoadCACerts(c.caCertFile)
 	if err == nil && certPool != nil {
-		transport := &http.Transport{
-			TLSClientConfig: &tls.Config{
-				RootCAs: certPool,
-			},
+		c.transport().TLSClientConfig = &tls.Config{
+			RootCAs: certPool,
 		}
+	}
+}
+
+// transport 返回客户端的 http.Transport，不存在时创建
+// 代理和 TLS 设置都修改同一个 Transport，互不覆盖
+func (c *Client) transport() *http.Transport {
+	transport, ok := c.httpClient.Transport.(*http.Transport)
+	if !ok {
+		transport = &http.Transport{}
 		c.httpClient.Transport = transport
 	}
+	return transport
 }
 
 // SetHeaders 设置请求头
@@ -186,11 +195,12 @@ func (c *Client) SetProxy(proxyURL string) error {
 		return fmt.Errorf("invalid proxy URL: %w", err)
 	}
 
-	transport := &http.Transport{
-		Proxy: http.ProxyURL(proxy),
-	}
-
-	c.httpClient.Transport = transport
+	// 复制 Transport 和 http.Client，避免修改与复制出的客户端共用的配置，同时保留 TLS 设置
+	transport := c.transport().Clone()
+	transport.Proxy = http.ProxyURL(proxy)
+	httpClient := *c.httpClient
+	httpClient.Transport = transport
+	c.httpClient = &httpClient
 	c.proxy = proxyURL
 	return nil
 }
@@ -219,18 +229,26 @@ func (c *Client) GetImpersonateOS() ImpersonateOS {
 
 // Request 使用指定方法和 URL 执行 HTTP 请求
 func (c *Client) Request(method HttpMethod, urlStr string, params RequestParams) (*Response, error) {
-	// 创建带有超时的上下文
-	ctx := context.Background()
-	if params.Timeout > 0 {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, params.Timeout)
-		defer cancel()
-	} else if c.timeout > 0 {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, c.timeout)
-		defer cancel()
+	prepared, err := c.Prepare(method, urlStr, params)
+	if err != nil {
+		return nil, err
 	}
+	return c.Send(prepared)
+}
+
+// PreparedRequest 是已构建但尚未发送的请求，发送前可以修改 Request 的头部
+// 请求体只能发送一次
+type PreparedRequest struct {
+	Request *http.Request
+
+	method HttpMethod
+	params RequestParams
+	form   *Multipart
+	client *Client
+}
 
+// Prepare 构建请求但不发送，multipart 请求体在发送时才生成
+func (c *Client) Prepare(method HttpMethod, urlStr string, params RequestParams) (*PreparedRequest, error) {
 	// 准备带有查询参数的 URL
 	reqURL, err := url.Parse(urlStr)
 	if err != nil {
@@ -238,78 +256,61 @@ func (c *Client) Request(method HttpMethod, urlStr string, params RequestParams)
 	}
 
 	// 添加查询参数
-	q := reqURL.Query()
-	if params.Params != nil {
-		for k, v := range params.Params {
-			q.Add(k, v)
-		}
-	}
-	if c.params != nil {
-		for k, v := range c.params {
-			q.Add(k, v)
-		}
-	}
-	reqURL.RawQuery = q.Encode()
+	reqURL.RawQuery = c.buildQuery(reqURL.RawQuery, params)
 
 	// 创建请求体
 	var body io.Reader
 	var contentType string
-
-	// 仅当方法为 POST、PUT 或 PATCH 时
-	if method == POST || method == PUT || method == PATCH {
-		if params.Content != nil {
-			body = bytes.NewReader(params.Content)
-		} else if params.Data != nil {
-			formData := url.Values{}
-			for k, v := range params.Data {
-				formData.Add(k, fmt.Sprintf("%v", v))
-			}
-			body = strings.NewReader(formData.Encode())
-			contentType = "application/x-www-form-urlencoded"
-		} else if params.JSON != nil {
-			jsonData, err := json.Marshal(params.JSON)
-			if err != nil {
-				return nil, fmt.Errorf("failed to marshal JSON: %w", err)
-			}
-			body = bytes.NewReader(jsonData)
-			contentType = "application/json"
-		} else if params.Files != nil {
-			var b bytes.Buffer
-			w := multipart.NewWriter(&b)
-
-			for fieldName, filePath := range params.Files {
-				file, err := os.Open(filePath)
-				if err != nil {
-					return nil, fmt.Errorf("failed to open file %s: %w", filePath, err)
-				}
-				defer file.Close()
-
-				part, err := w.CreateFormFile(fieldName, filepath.Base(filePath))
-				if err != nil {
-					return nil, fmt.Errorf("failed to create form file: %w", err)
-				}
-
-				_, err = io.Copy(part, file)
-				if err != nil {
-					return nil, fmt.Errorf("failed to copy file content: %w", err)
-				}
-			}
-
-			err = w.Close()
-			if err != nil {
-				return nil, fmt.Errorf("failed to close multipart writer: %w", err)
-			}
-
-			body = &b
-			contentType = w.FormDataContentType()
+	var contentLength int64
+	var form *Multipart
+	fields := append(dataPairs(params.Data), params.DataPairs...)
+
+	// 任何方法都可以携带请求体
+	if params.Content != nil {
+		body = bytes.NewReader(params.Content)
+	} else if params.Body != nil {
+		body = params.Body
+		contentLength = params.ContentLength
+	} else if params.Multipart != nil {
+		form = params.Multipart.withFields(fields)
+	} else if params.Files != nil {
+		// 文件与 Data 中的字段一起以 multipart 编码发送
+		form = NewMultipart().withFields(fields)
+		fieldNames := make([]string, 0, len(params.Files))
+		for fieldName := range params.Files {
+			fieldNames = append(fieldNames, fieldName)
+		}
+		sort.Strings(fieldNames)
+		for _, fieldName := range fieldNames {
+			form.AddFile(fieldName, params.Files[fieldName])
+		}
+	} else if params.Data != nil || params.DataPairs != nil {
+		body = strings.NewReader(encodePairs(fields))
+		contentType = "application/x-www-form-urlencoded"
+	} else if params.JSON != nil {
+		jsonData, err := json.Marshal(params.JSON)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal JSON: %w", err)
 		}
+		body = bytes.NewReader(jsonData)
+		contentType = "application/json"
+	}
+	if form != nil {
+		contentType = form.ContentType()
 	}
 
 	// 创建请求
-	req, err := http.NewRequestWithContext(ctx, string(method), reqURL.String(), body)
+	parent := params.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+	req, err := http.NewRequestWithContext(parent, string(method), reqURL.String(), body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	if contentLength != 0 {
+		req.ContentLength = contentLength
+	}
 
 	// 设置内容类型
 	if contentType != "" {
@@ -355,14 +356,63 @@ func (c *Client) Request(method HttpMethod, urlStr string, params RequestParams)
 		req.Header.Set("Referer", referer)
 	}
 
+	return &PreparedRequest{Request: req, method: method, params: params, form: form, client: c}, nil
+}
+
+// Send 发送预先构建的请求
+func (c *Client) Send(prepared *PreparedRequest) (*Response, error) {
+	req := prepared.Request
+	params := prepared.params
+
+	// 创建带有超时的上下文，由 Response 在关闭时取消，超时同时限制读取响应体的时间
+	timeout := c.timeout
+	if params.Timeout != 0 {
+		timeout = params.Timeout
+	}
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(req.Context(), timeout)
+	} else {
+		ctx, cancel = context.WithCancel(req.Context())
+	}
+	req = req.WithContext(ctx)
+
+	if prepared.form != nil {
+		formBody, size, err := prepared.form.reader(ctx)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		req.Body = formBody
+		req.ContentLength = size
+	}
+	if params.OnUploadProgress != nil && req.Body != nil && req.Body != http.NoBody {
+		req.Body = newProgressReader(req.Body, req.ContentLength, params.OnUploadProgress)
+	}
+
 	// 发送请求
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.roundTrip(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		cancel()
+		return nil, newRequestError(prepared.method, req.URL.String(), err)
 	}
 
-	// 创建响应
-	return newResponse(resp, reqURL.String())
+	if params.OnDownloadProgress != nil {
+		resp.Body = newProgressReader(resp.Body, resp.ContentLength, params.OnDownloadProgress)
+	}
+
+	// 创建响应，URL 为跟随重定向后的最终地址
+	maxBodySize := c.maxBodySize
+	if params.MaxBodySize != 0 {
+		maxBodySize = max(params.MaxBodySize, 0)
+	}
+	response, err := newResponse(resp, resp.Request.URL.String(), cancel, maxBodySize, params.Stream)
+	if err != nil {
+		return nil, err
+	}
+	response.client = c
+	return response, nil
 }
 
 // Get 发送 GET 请求
@@ -0,0 +1,357 @@
+package primp
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestProxyKeptWithTLSSettings(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("via proxy " + r.URL.String()))
+	}))
+	defer proxy.Close()
+
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer tlsServer.Close()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsServer.Certificate().Raw})
+	if err := os.WriteFile(caFile, certPEM, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		options []Option
+	}{
+		{name: "verify disabled", options: []Option{WithProxy(proxy.URL), WithVerify(false)}},
+		{name: "custom CA", options: []Option{WithProxy(proxy.URL), WithCACertFile(caFile)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := NewClient(tt.options...).Get("http://example.invalid/path")
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			text, _ := resp.Text()
+			if text != "via proxy http://example.invalid/path" {
+				t.Errorf("response = %q, want it served by the proxy", text)
+			}
+		})
+	}
+}
+
+func TestSetProxyKeepsTLSSettings(t *testing.T) {
+	client := NewClient(WithVerify(false))
+	if err := client.SetProxy("http://127.0.0.1:8080"); err != nil {
+		t.Fatalf("SetProxy() error = %v", err)
+	}
+	transport := client.httpClient.Transport.(*http.Transport)
+	if transport.Proxy == nil {
+		t.Error("proxy not set")
+	}
+	if transport.TLSClientConfig == nil || !transport.TLSClientConfig.InsecureSkipVerify {
+		t.Error("SetProxy dropped InsecureSkipVerify")
+	}
+}
+
+func TestSetProxyLeavesSharedTransport(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("via proxy"))
+	}))
+	defer proxy.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("direct"))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithVerify(false))
+	clone := *client
+	shared := client.httpClient.Transport.(*http.Transport)
+	if err := client.SetProxy(proxy.URL); err != nil {
+		t.Fatalf("SetProxy() error = %v", err)
+	}
+
+	if shared.Proxy != nil {
+		t.Error("SetProxy changed the Proxy of the shared transport")
+	}
+	if clone.httpClient.Transport != shared {
+		t.Error("SetProxy replaced the transport of the cloned client")
+	}
+	for name, tt := range map[string]struct {
+		client *Client
+		want   string
+	}{
+		"proxied client": {client, "via proxy"},
+		"cloned client":  {&clone, "direct"},
+	} {
+		resp, err := tt.client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("%s: Get() error = %v", name, err)
+		}
+		if text, _ := resp.Text(); text != tt.want {
+			t.Errorf("%s: response = %q, want %q", name, text, tt.want)
+		}
+	}
+}
+
+// echoServer 返回请求的方法、长度、传输编码和请求体
+func echoServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Method", r.Method)
+		w.Header().Set("X-Content-Length", strconv.FormatInt(r.ContentLength, 10))
+		w.Header().Set("X-Transfer-Encoding", strings.Join(r.TransferEncoding, ","))
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRequestBodyContentLength(t *testing.T) {
+	server := echoServer(t)
+	tests := []struct {
+		name          string
+		body          io.Reader
+		contentLength int64
+		wantLength    string
+		wantEncoding  string
+	}{
+		{"detected from strings.Reader", strings.NewReader("hello"), 0, "5", ""},
+		{"unknown reader is chunked", iotest.OneByteReader(strings.NewReader("hello")), 0, "-1", "chunked"},
+		{"explicit length", iotest.OneByteReader(strings.NewReader("hello")), 5, "5", ""},
+		{"forced chunked", strings.NewReader("hello"), -1, "-1", "chunked"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := NewClient().Put(server.URL, RequestParams{Body: tt.body, ContentLength: tt.contentLength})
+			if err != nil {
+				t.Fatalf("Put() error = %v", err)
+			}
+			if text, _ := resp.Text(); text != "hello" {
+				t.Errorf("server received %q, want %q", text, "hello")
+			}
+			if got := resp.httpResp.Header.Get("X-Content-Length"); got != tt.wantLength {
+				t.Errorf("Content-Length = %s, want %s", got, tt.wantLength)
+			}
+			if got := resp.httpResp.Header.Get("X-Transfer-Encoding"); got != tt.wantEncoding {
+				t.Errorf("Transfer-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+		})
+	}
+}
+
+func TestRequestBodyClosedAfterSend(t *testing.T) {
+	server := echoServer(t)
+	body := &closeRecorder{Reader: strings.NewReader("payload")}
+	resp, err := NewClient().Post(server.URL, RequestParams{Body: body})
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	if text, _ := resp.Text(); text != "payload" {
+		t.Errorf("server received %q, want %q", text, "payload")
+	}
+	if !body.closed.Load() {
+		t.Error("Body not closed after sending")
+	}
+}
+
+func TestRequestCustomMethodsWithBody(t *testing.T) {
+	server := echoServer(t)
+	tests := []struct {
+		method HttpMethod
+		body   string
+	}{
+		{HttpMethod("PROPFIND"), `<propfind xmlns="DAV:"><allprop/></propfind>`},
+		{DELETE, `{"ids":[1,2]}`},
+		{GET, "query"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.method), func(t *testing.T) {
+			resp, err := NewClient().Request(tt.method, server.URL, RequestParams{Content: []byte(tt.body)})
+			if err != nil {
+				t.Fatalf("Request() error = %v", err)
+			}
+			if got := resp.httpResp.Header.Get("X-Method"); got != string(tt.method) {
+				t.Errorf("method = %q, want %q", got, tt.method)
+			}
+			if text, _ := resp.Text(); text != tt.body {
+				t.Errorf("server received %q, want %q", text, tt.body)
+			}
+		})
+	}
+}
+
+func TestRequestBodyPrecedence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "upload.txt")
+	if err := os.WriteFile(file, []byte("file body"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	all := RequestParams{
+		Content:   []byte("content"),
+		Body:      strings.NewReader("body"),
+		Multipart: NewMultipart().AddField("part", "multipart"),
+		Files:     map[string]string{"file": file},
+		Data:      map[string]interface{}{"field": "data"},
+		JSON:      map[string]string{"json": "value"},
+	}
+
+	tests := []struct {
+		name        string
+		params      func() RequestParams
+		wantBody    string
+		contentType string
+	}{
+		{"content first", func() RequestParams { return all }, "content", ""},
+		{"body before multipart", func() RequestParams { p := all; p.Content = nil; return p }, "body", ""},
+		{"multipart before files", func() RequestParams { p := all; p.Content, p.Body = nil, nil; return p }, `name="part"`, "multipart/form-data"},
+		{"files before data", func() RequestParams {
+			p := all
+			p.Content, p.Body, p.Multipart = nil, nil, nil
+			return p
+		}, "file body", "multipart/form-data"},
+		{"data before json", func() RequestParams {
+			p := all
+			p.Content, p.Body, p.Multipart, p.Files = nil, nil, nil, nil
+			return p
+		}, "field=data", "application/x-www-form-urlencoded"},
+		{"json last", func() RequestParams { return RequestParams{JSON: all.JSON} }, `{"json":"value"}`, "application/json"},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Content-Type", r.Header.Get("Content-Type"))
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := NewClient().Post(server.URL, tt.params())
+			if err != nil {
+				t.Fatalf("Post() error = %v", err)
+			}
+			text, _ := resp.Text()
+			if !strings.Contains(text, tt.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", text, tt.wantBody)
+			}
+			if got := resp.httpResp.Header.Get("X-Content-Type"); !strings.HasPrefix(got, tt.contentType) {
+				t.Errorf("Content-Type = %q, want prefix %q", got, tt.contentType)
+			}
+		})
+	}
+}
+
+// blockingServer 返回在 release 关闭或请求取消前不返回的服务器，/body 先发送部分响应体再阻塞
+// started 在每个请求到达时收到一个值
+func blockingServer(t *testing.T) (server *httptest.Server, started <-chan struct{}, release func()) {
+	t.Helper()
+	arrived := make(chan struct{}, 16)
+	done := make(chan struct{})
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/body" {
+			w.Write([]byte("partial"))
+			w.(http.Flusher).Flush()
+		}
+		arrived <- struct{}{}
+		select {
+		case <-done:
+			w.Write([]byte("released"))
+		case <-r.Context().Done():
+		}
+	}))
+	var once sync.Once
+	release = func() { once.Do(func() { close(done) }) }
+	// 先放行阻塞的处理函数，server.Close 才能返回
+	t.Cleanup(server.Close)
+	t.Cleanup(release)
+	return server, arrived, release
+}
+
+func TestRequestTimeout(t *testing.T) {
+	server, _, _ := blockingServer(t)
+	client := NewClient()
+	if client.httpClient.Timeout != 0 {
+		t.Errorf("http.Client.Timeout = %v, want 0 so the per-request context controls the deadline", client.httpClient.Timeout)
+	}
+
+	if _, err := client.Get(server.URL, RequestParams{Timeout: 50 * time.Millisecond}); !errors.Is(err, ErrTimeout) {
+		t.Errorf("Get() error = %v, want %v", err, ErrTimeout)
+	}
+
+	// 超时同样限制读取响应体的时间
+	resp, err := client.Get(server.URL+"/body", RequestParams{Timeout: 50 * time.Millisecond, Stream: true})
+	if err != nil {
+		t.Fatalf("Get(/body) error = %v", err)
+	}
+	if _, err := resp.Content(); !errors.Is(err, ErrTimeout) {
+		t.Errorf("Content() error = %v, want %v", err, ErrTimeout)
+	}
+
+	// 客户端的超时作为默认值
+	client.timeout = 50 * time.Millisecond
+	if _, err := client.Get(server.URL); !errors.Is(err, ErrTimeout) {
+		t.Errorf("Get() with client timeout error = %v, want %v", err, ErrTimeout)
+	}
+}
+
+func TestRequestNegativeTimeoutDisablesClientTimeout(t *testing.T) {
+	server, started, release := blockingServer(t)
+	client := NewClient()
+	client.timeout = 50 * time.Millisecond
+
+	go func() {
+		<-started
+		// 等待超过客户端超时时间后再放行
+		<-time.After(4 * client.timeout)
+		release()
+	}()
+	resp, err := client.Get(server.URL, RequestParams{Timeout: -1})
+	if err != nil {
+		t.Fatalf("Get() error = %v, want the request to outlive the client timeout", err)
+	}
+	if text, _ := resp.Text(); text != "released" {
+		t.Errorf("Text() = %q, want %q", text, "released")
+	}
+}
+
+func TestRequestContextCancel(t *testing.T) {
+	server, started, _ := blockingServer(t)
+	client := NewClient()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	_, err := client.Get(server.URL, RequestParams{Context: ctx})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Get() error = %v, want %v", err, context.Canceled)
+	}
+
+	// 取消父上下文同样中止响应体的读取
+	ctx, cancel = context.WithCancel(context.Background())
+	resp, err := client.Get(server.URL+"/body", RequestParams{Context: ctx, Stream: true})
+	if err != nil {
+		t.Fatalf("Get(/body) error = %v", err)
+	}
+	<-started
+	cancel()
+	if _, err := resp.Content(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Content() error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,127 @@
+package primp
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// decodedBody 按 Content-Encoding 解码响应体，解码器在首次读取时才创建
+type decodedBody struct {
+	source    io.Reader
+	encodings []string
+	reader    io.Reader
+	closers   []io.Closer
+	err       error
+}
+
+// newDecodedBody 创建解码读取器，encodings 为 Content-Encoding 头部中按应用顺序排列的编码
+func newDecodedBody(source io.Reader, contentEncoding string) *decodedBody {
+	var encodings []string
+	for _, enc := range strings.Split(contentEncoding, ",") {
+		enc = strings.ToLower(strings.TrimSpace(enc))
+		if enc != "" && enc != "identity" {
+			encodings = append(encodings, enc)
+		}
+	}
+
+	return &decodedBody{
+		source:    source,
+		encodings: encodings,
+	}
+}
+
+// Read 实现 io.Reader
+func (d *decodedBody) Read(p []byte) (int, error) {
+	if d.reader == nil && d.err == nil {
+		d.err = d.init()
+	}
+	if d.err != nil {
+		return 0, d.err
+	}
+	return d.reader.Read(p)
+}
+
+// Close 关闭所有已创建的解码器
+func (d *decodedBody) Close() error {
+	var firstErr error
+	for i := len(d.closers) - 1; i >= 0; i-- {
+		if err := d.closers[i].Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	d.closers = nil
+	return firstErr
+}
+
+// init 按编码的逆序构建解码链
+func (d *decodedBody) init() error {
+	reader := bufio.NewReader(d.source)
+	// 空响应体（如 HEAD、204）无需解码
+	if _, err := reader.Peek(1); err == io.EOF {
+		d.reader = reader
+		return nil
+	}
+
+	var current io.Reader = reader
+	for i := len(d.encodings) - 1; i >= 0; i-- {
+		decoder, err := newDecoder(current, d.encodings[i])
+		if err != nil {
+			d.Close()
+			return err
+		}
+		if closer, ok := decoder.(io.Closer); ok {
+			d.closers = append(d.closers, closer)
+		}
+		current = decoder
+	}
+
+	d.reader = current
+	return nil
+}
+
+// newDecoder 返回指定内容编码的解码器
+func newDecoder(reader io.Reader, encoding string) (io.Reader, error) {
+	switch encoding {
+	case "gzip", "x-gzip":
+		decoder, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode gzip body: %w", err)
+		}
+		return decoder, nil
+	case "deflate":
+		return newDeflateReader(reader)
+	case "br":
+		return brotli.NewReader(reader), nil
+	case "zstd":
+		decoder, err := zstd.NewReader(reader, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode zstd body: %w", err)
+		}
+		return decoder.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("unsupported content encoding: %s", encoding)
+	}
+}
+
+// newDeflateReader 解码 deflate 响应体
+// 规范要求 zlib 封装，但部分服务器发送裸 deflate 数据，这里根据 zlib 头部自动判断
+func newDeflateReader(reader io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(reader)
+	header, err := buffered.Peek(2)
+	if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		decoder, err := zlib.NewReader(buffered)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode deflate body: %w", err)
+		}
+		return decoder, nil
+	}
+	return flate.NewReader(buffered), nil
+}
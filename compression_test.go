@@ -0,0 +1,130 @@
+package primp
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+const compressionText = "hello, compressed world! hello, compressed world!"
+
+// compressWith 使用 encoding 对应的编码器压缩 data
+func compressWith(t *testing.T, encoding string, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "zlib":
+		w = zlib.NewWriter(&buf)
+	case "flate":
+		fw, err := flate.NewWriter(&buf, flate.DefaultCompression)
+		if err != nil {
+			t.Fatalf("flate.NewWriter() error = %v", err)
+		}
+		w = fw
+	case "br":
+		w = brotli.NewWriter(&buf)
+	case "zstd":
+		zw, err := zstd.NewWriter(&buf)
+		if err != nil {
+			t.Fatalf("zstd.NewWriter() error = %v", err)
+		}
+		w = zw
+	default:
+		t.Fatalf("unknown encoding %q", encoding)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodedBody(t *testing.T) {
+	plain := []byte(compressionText)
+	tests := []struct {
+		name            string
+		contentEncoding string
+		body            func(t *testing.T) []byte
+		wantErr         string
+		wantEmpty       bool
+	}{
+		{"identity", "", func(t *testing.T) []byte { return plain }, "", false},
+		{"explicit identity", "identity", func(t *testing.T) []byte { return plain }, "", false},
+		{"gzip", "gzip", func(t *testing.T) []byte { return compressWith(t, "gzip", plain) }, "", false},
+		{"x-gzip upper case", "X-GZIP", func(t *testing.T) []byte { return compressWith(t, "gzip", plain) }, "", false},
+		{"zlib deflate", "deflate", func(t *testing.T) []byte { return compressWith(t, "zlib", plain) }, "", false},
+		{"raw deflate", "deflate", func(t *testing.T) []byte { return compressWith(t, "flate", plain) }, "", false},
+		{"br", "br", func(t *testing.T) []byte { return compressWith(t, "br", plain) }, "", false},
+		{"zstd", "zstd", func(t *testing.T) []byte { return compressWith(t, "zstd", plain) }, "", false},
+		{
+			name:            "chained gzip then br",
+			contentEncoding: "gzip, br",
+			body: func(t *testing.T) []byte {
+				return compressWith(t, "br", compressWith(t, "gzip", plain))
+			},
+		},
+		{"unknown encoding", "compress", func(t *testing.T) []byte { return []byte("data") }, "unsupported content encoding: compress", false},
+		{"empty body", "gzip", func(t *testing.T) []byte { return nil }, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := newDecodedBody(bytes.NewReader(tt.body(t)), tt.contentEncoding)
+			defer reader.Close()
+			got, err := io.ReadAll(reader)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ReadAll() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadAll() error = %v", err)
+			}
+			want := plain
+			if tt.wantEmpty {
+				want = nil
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("ReadAll() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestResponseContentDecodesRawContentDoesNot(t *testing.T) {
+	compressed := compressWith(t, "br", compressWith(t, "gzip", []byte(compressionText)))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip, br")
+		w.Write(compressed)
+	}))
+	defer server.Close()
+
+	// 显式设置 Accept-Encoding，避免 net/http 自行解压
+	resp, err := NewClient().Get(server.URL, RequestParams{Headers: map[string]string{"Accept-Encoding": "gzip, br"}})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	content, err := resp.Content()
+	if err != nil || string(content) != compressionText {
+		t.Errorf("Content() = %q, %v; want %q", content, err, compressionText)
+	}
+	raw, err := resp.RawContent()
+	if err != nil || !bytes.Equal(raw, compressed) {
+		t.Errorf("RawContent() = %q, %v; want the undecoded bytes", raw, err)
+	}
+}
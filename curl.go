@@ -0,0 +1,813 @@
+package primp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// CurlRequest 是从 curl 命令解析出的请求
+// Proxy 和 Insecure 属于客户端设置，可以通过 WithProxy 和 WithVerify(false) 应用
+type CurlRequest struct {
+	Method   HttpMethod
+	URL      string
+	Params   RequestParams
+	Proxy    string
+	Insecure bool
+}
+
+// curlShortOptions 是 curl 短选项对应的长选项
+var curlShortOptions = map[byte]string{
+	'X': "--request",
+	'H': "--header",
+	'b': "--cookie",
+	'd': "--data",
+	'F': "--form",
+	'u': "--user",
+	'x': "--proxy",
+	'k': "--insecure",
+	'I': "--head",
+	'G': "--get",
+	'A': "--user-agent",
+	'e': "--referer",
+	'm': "--max-time",
+	'o': "--output",
+	'L': "--location",
+	's': "--silent",
+	'S': "--show-error",
+	'v': "--verbose",
+	'i': "--include",
+	'g': "--globoff",
+	'f': "--fail",
+	'N': "--no-buffer",
+}
+
+// curlArgOptions 是需要参数的 curl 选项
+var curlArgOptions = map[string]bool{
+	"--request":         true,
+	"--header":          true,
+	"--cookie":          true,
+	"--data":            true,
+	"--data-ascii":      true,
+	"--data-raw":        true,
+	"--data-binary":     true,
+	"--data-urlencode":  true,
+	"--form":            true,
+	"--form-string":     true,
+	"--user":            true,
+	"--proxy":           true,
+	"--user-agent":      true,
+	"--referer":         true,
+	"--max-time":        true,
+	"--url":             true,
+	"--output":          true,
+	"--connect-timeout": true,
+}
+
+// curlIgnoredOptions 是不影响请求内容的 curl 选项，解析时忽略
+var curlIgnoredOptions = map[string]bool{
+	"--location":              true,
+	"--silent":                true,
+	"--show-error":            true,
+	"--verbose":               true,
+	"--include":               true,
+	"--globoff":               true,
+	"--fail":                  true,
+	"--no-buffer":             true,
+	"--http1.1":               true,
+	"--http2":                 true,
+	"--http2-prior-knowledge": true,
+	"--output":                true,
+	"--connect-timeout":       true,
+}
+
+// FromCurl 解析 curl 命令（如浏览器开发者工具中的 "Copy as cURL"），支持 bash 风格的引号和续行
+// 支持 -X、-H、-b、-d/--data-raw/--data-binary/--data-urlencode、-F/--form-string、-u、-G、-I、-A、-e、-m、-k、-x 和 --compressed
+// -d @file、--data-urlencode name@file 和 -F name=<file 在解析时读取本地文件，-F name=@file 在发送时读取，
+// 因此不要解析不可信的命令；不支持从标准输入读取数据（@- 和 <-）
+func FromCurl(cmd string) (*CurlRequest, error) {
+	args, err := splitCommand(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 || args[0] != "curl" {
+		return nil, fmt.Errorf("not a curl command")
+	}
+
+	p := &curlParser{
+		request: &CurlRequest{},
+		headers: make(map[string]string),
+		removed: make(map[string]bool),
+		cookies: make(map[string]string),
+	}
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case strings.HasPrefix(arg, "--") && len(arg) > 2:
+			value := ""
+			if curlArgOptions[arg] {
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("curl option %s requires a value", arg)
+				}
+				i++
+				value = args[i]
+			}
+			if err := p.apply(arg, value); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			// 短选项可以合并（如 -sSL），参数可以紧跟选项（如 -XPOST）
+			for j := 1; j < len(arg); j++ {
+				option, ok := curlShortOptions[arg[j]]
+				if !ok {
+					return nil, fmt.Errorf("unsupported curl option -%c", arg[j])
+				}
+				if !curlArgOptions[option] {
+					if err := p.apply(option, ""); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				value := arg[j+1:]
+				if value == "" {
+					if i+1 >= len(args) {
+						return nil, fmt.Errorf("curl option -%c requires a value", arg[j])
+					}
+					i++
+					value = args[i]
+				}
+				if err := p.apply(option, value); err != nil {
+					return nil, err
+				}
+				break
+			}
+		default:
+			if err := p.apply("--url", arg); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return p.finish()
+}
+
+// curlParser 保存解析 curl 命令的中间状态
+type curlParser struct {
+	request    *CurlRequest
+	headers    map[string]string
+	removed    map[string]bool
+	cookies    map[string]string
+	data       []string
+	hasData    bool
+	form       *Multipart
+	get        bool
+	head       bool
+	compressed bool
+}
+
+// apply 处理一个 curl 选项
+func (p *curlParser) apply(option, value string) error {
+	switch option {
+	case "--url":
+		if p.request.URL != "" {
+			return fmt.Errorf("curl command contains more than one URL")
+		}
+		p.request.URL = value
+	case "--request":
+		p.request.Method = HttpMethod(strings.ToUpper(value))
+	case "--header":
+		name, headerValue, ok := strings.Cut(value, ":")
+		if !ok {
+			// "Name;" 表示发送空值的头部
+			if !strings.HasSuffix(value, ";") {
+				return fmt.Errorf("invalid curl header: %q", value)
+			}
+			name = strings.TrimSuffix(value, ";")
+		}
+		name = strings.TrimSpace(name)
+		headerValue = strings.TrimSpace(headerValue)
+		if ok && headerValue == "" {
+			// "Name:" 表示删除头部，包括 curl 默认添加的头部
+			delete(p.headers, http.CanonicalHeaderKey(name))
+			p.removed[http.CanonicalHeaderKey(name)] = true
+			return nil
+		}
+		if strings.EqualFold(name, "Cookie") {
+			parseCookieHeader(headerValue, p.cookies)
+			return nil
+		}
+		p.headers[http.CanonicalHeaderKey(name)] = headerValue
+		delete(p.removed, http.CanonicalHeaderKey(name))
+	case "--cookie":
+		if !strings.Contains(value, "=") {
+			return fmt.Errorf("curl cookie files are not supported: %q", value)
+		}
+		parseCookieHeader(value, p.cookies)
+	case "--data", "--data-ascii", "--data-binary":
+		if strings.HasPrefix(value, "@") {
+			content, err := readCurlFile(value[1:])
+			if err != nil {
+				return err
+			}
+			value = string(content)
+			if option != "--data-binary" {
+				value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+			}
+		}
+		p.addData(value)
+	case "--data-raw":
+		p.addData(value)
+	case "--data-urlencode":
+		encoded, err := urlencodeCurlData(value)
+		if err != nil {
+			return err
+		}
+		p.addData(encoded)
+	case "--form", "--form-string":
+		if p.form == nil {
+			p.form = NewMultipart()
+		}
+		return addCurlFormPart(p.form, value, option == "--form-string")
+	case "--user":
+		username, password, _ := strings.Cut(value, ":")
+		p.request.Params.Auth = &BasicAuth{Username: username, Password: password}
+	case "--proxy":
+		p.request.Proxy = value
+	case "--insecure":
+		p.request.Insecure = true
+	case "--head":
+		p.head = true
+	case "--get":
+		p.get = true
+	case "--compressed":
+		p.compressed = true
+	case "--user-agent":
+		p.headers["User-Agent"] = value
+	case "--referer":
+		p.headers["Referer"] = strings.TrimSuffix(value, ";auto")
+	case "--max-time":
+		seconds, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid curl max time: %q", value)
+		}
+		p.request.Params.Timeout = time.Duration(seconds * float64(time.Second))
+	default:
+		if !curlIgnoredOptions[option] {
+			return fmt.Errorf("unsupported curl option %s", option)
+		}
+	}
+	return nil
+}
+
+// addData 添加 -d 系列选项的数据，多个数据以 & 连接
+func (p *curlParser) addData(value string) {
+	p.data = append(p.data, value)
+	p.hasData = true
+}
+
+// finish 根据解析结果确定方法、URL 和请求参数
+func (p *curlParser) finish() (*CurlRequest, error) {
+	r := p.request
+	if r.URL == "" {
+		return nil, fmt.Errorf("curl command has no URL")
+	}
+	if p.hasData && p.form != nil {
+		return nil, fmt.Errorf("curl command cannot mix --data and --form")
+	}
+
+	data := strings.Join(p.data, "&")
+	if p.hasData && p.get {
+		// -G 将数据附加到 URL 查询字符串
+		separator := "?"
+		if strings.Contains(r.URL, "?") {
+			separator = "&"
+		}
+		r.URL += separator + data
+	}
+
+	defaultMethod := GET
+	switch {
+	case p.head:
+		defaultMethod = HEAD
+	case p.hasData && !p.get:
+		defaultMethod = POST
+		r.Params.Content = []byte(data)
+		if _, ok := p.headers["Content-Type"]; !ok && !p.removed["Content-Type"] {
+			p.headers["Content-Type"] = "application/x-www-form-urlencoded"
+		}
+	case p.form != nil:
+		defaultMethod = POST
+		r.Params.Multipart = p.form
+		// boundary 由 Multipart 生成
+		delete(p.headers, "Content-Type")
+	}
+	if r.Method == "" {
+		r.Method = defaultMethod
+	}
+
+	if _, ok := p.headers["Accept-Encoding"]; p.compressed && !ok && !p.removed["Accept-Encoding"] {
+		p.headers["Accept-Encoding"] = "gzip, deflate, br, zstd"
+	}
+	if len(p.headers) > 0 {
+		r.Params.Headers = p.headers
+	}
+	if len(p.cookies) > 0 {
+		r.Params.Cookies = p.cookies
+	}
+	return r, nil
+}
+
+// parseCookieHeader 解析 "a=1; b=2" 格式的 cookie
+func parseCookieHeader(header string, cookies map[string]string) {
+	for _, pair := range strings.Split(header, ";") {
+		name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if ok && name != "" {
+			cookies[name] = value
+		}
+	}
+}
+
+// urlencodeCurlData 按 --data-urlencode 的规则编码数据，支持 content、=content、name=content、@file 和 name@file
+func urlencodeCurlData(value string) (string, error) {
+	if i := strings.IndexAny(value, "=@"); i >= 0 {
+		name, content := value[:i], value[i+1:]
+		if value[i] == '@' {
+			data, err := readCurlFile(content)
+			if err != nil {
+				return "", err
+			}
+			content = string(data)
+		}
+		if name == "" {
+			return url.QueryEscape(content), nil
+		}
+		return name + "=" + url.QueryEscape(content), nil
+	}
+	return url.QueryEscape(value), nil
+}
+
+// addCurlFormPart 解析 -F 的参数，支持 name=value、name=@file 和 name=<file，以及 ;type=、;filename=、;headers= 参数
+// 带 filename 的普通内容作为文件部分发送
+func addCurlFormPart(form *Multipart, value string, literal bool) error {
+	name, content, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("invalid curl form field: %q", value)
+	}
+	if literal {
+		form.AddField(name, content)
+		return nil
+	}
+
+	source := ""
+	if strings.HasPrefix(content, "@") || strings.HasPrefix(content, "<") {
+		source, content = content[:1], content[1:]
+	}
+	content, params, err := parseCurlFormParams(content)
+	if err != nil {
+		return err
+	}
+	var opts []PartOption
+	hasFilename := false
+	for _, param := range params {
+		switch param[0] {
+		case "type":
+			opts = append(opts, PartContentType(param[1]))
+		case "filename":
+			opts = append(opts, PartFilename(param[1]))
+			hasFilename = true
+		case "headers":
+			if key, headerValue, ok := strings.Cut(param[1], ":"); ok {
+				opts = append(opts, PartHeader(strings.TrimSpace(key), strings.TrimSpace(headerValue)))
+			}
+		}
+	}
+
+	data := []byte(content)
+	switch source {
+	case "@":
+		if content == "-" {
+			return errCurlStdin
+		}
+		form.AddFile(name, content, opts...)
+		return nil
+	case "<":
+		if data, err = readCurlFile(content); err != nil {
+			return err
+		}
+	}
+	if hasFilename {
+		form.AddBytes(name, "", data, opts...)
+		return nil
+	}
+	part := &multipartPart{name: name, header: textproto.MIMEHeader{}, value: data, size: int64(len(data))}
+	for _, opt := range opts {
+		opt(part)
+	}
+	form.parts = append(form.parts, part)
+	return nil
+}
+
+// curlFormParamKeys 是 -F 参数中可以跟在值后面的参数名
+var curlFormParamKeys = []string{"type=", "filename=", "headers=", "encoder="}
+
+// parseCurlFormParams 拆分 -F 参数中的值和后面的 ;key=value 参数
+func parseCurlFormParams(s string) (string, [][2]string, error) {
+	value, rest, err := cutCurlFormWord(s)
+	if err != nil {
+		return "", nil, err
+	}
+	var params [][2]string
+	for rest != "" {
+		key, after, ok := strings.Cut(strings.TrimLeft(rest[1:], " \t"), "=")
+		if !ok {
+			return "", nil, fmt.Errorf("invalid curl form parameter: %q", rest)
+		}
+		var paramValue string
+		if paramValue, rest, err = cutCurlFormWord(after); err != nil {
+			return "", nil, err
+		}
+		params = append(params, [2]string{key, paramValue})
+	}
+	return value, params, nil
+}
+
+// cutCurlFormWord 读取一个可以用双引号引用的值，返回值和以 ";" 开头的剩余部分
+// 未引用的值只在后面跟着已知参数的 ";" 处结束，因此可以包含 "text/plain; charset=utf-8" 中的分号
+func cutCurlFormWord(s string) (string, string, error) {
+	if strings.HasPrefix(s, `"`) {
+		var sb strings.Builder
+		for i := 1; i < len(s); i++ {
+			switch {
+			case s[i] == '\\' && i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\\'):
+				i++
+				sb.WriteByte(s[i])
+			case s[i] == '"':
+				rest := strings.TrimLeft(s[i+1:], " \t")
+				if rest != "" && rest[0] != ';' {
+					return "", "", fmt.Errorf("invalid curl form value: %q", s)
+				}
+				return sb.String(), rest, nil
+			default:
+				sb.WriteByte(s[i])
+			}
+		}
+		return "", "", fmt.Errorf("unterminated quote in curl form value: %q", s)
+	}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != ';' {
+			continue
+		}
+		next := strings.TrimLeft(s[i+1:], " \t")
+		for _, key := range curlFormParamKeys {
+			if strings.HasPrefix(next, key) {
+				return s[:i], s[i:], nil
+			}
+		}
+	}
+	return s, "", nil
+}
+
+// errCurlStdin 表示 curl 命令要求从标准输入读取数据
+var errCurlStdin = errors.New("curl data from stdin (@-) is not supported")
+
+// readCurlFile 读取 curl 命令引用的本地文件，"-" 表示标准输入，不支持
+func readCurlFile(path string) ([]byte, error) {
+	if path == "-" {
+		return nil, errCurlStdin
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read curl data file: %w", err)
+	}
+	return data, nil
+}
+
+// splitCommand 按 bash 规则拆分命令行，支持单引号、双引号、$'...'、反斜杠转义和续行
+func splitCommand(cmd string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	for i := 0; i < len(cmd); i++ {
+		ch := cmd[i]
+		switch {
+		case ch == '\\':
+			if i+1 >= len(cmd) {
+				continue
+			}
+			if cmd[i+1] == '\n' {
+				i++
+				continue
+			}
+			if cmd[i+1] == '\r' && i+2 < len(cmd) && cmd[i+2] == '\n' {
+				i += 2
+				continue
+			}
+			current.WriteByte(cmd[i+1])
+			i++
+			inArg = true
+		case ch == '\'':
+			end := strings.IndexByte(cmd[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote in command")
+			}
+			current.WriteString(cmd[i+1 : i+1+end])
+			i += end + 1
+			inArg = true
+		case ch == '$' && i+1 < len(cmd) && cmd[i+1] == '\'':
+			n, err := unquoteANSI(cmd[i+2:], &current)
+			if err != nil {
+				return nil, err
+			}
+			i += n + 1
+			inArg = true
+		case ch == '"':
+			j := i + 1
+			for ; j < len(cmd) && cmd[j] != '"'; j++ {
+				if cmd[j] == '\\' && j+1 < len(cmd) && strings.IndexByte("\"\\$`\n", cmd[j+1]) >= 0 {
+					j++
+					if cmd[j] == '\n' {
+						continue
+					}
+				}
+				current.WriteByte(cmd[j])
+			}
+			if j >= len(cmd) {
+				return nil, fmt.Errorf("unterminated double quote in command")
+			}
+			i = j
+			inArg = true
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteByte(ch)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// unquoteANSI 解码 $'...' 的内容并写入 out，s 从开头的引号之后开始，返回包括结尾引号在内消耗的字节数
+func unquoteANSI(s string, out *strings.Builder) (int, error) {
+	simple := map[byte]byte{'a': '\a', 'b': '\b', 'e': 0x1b, 'E': 0x1b, 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t', 'v': '\v', '\\': '\\', '\'': '\'', '"': '"', '?': '?'}
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\'' {
+			return i + 1, nil
+		}
+		if s[i] != '\\' || i+1 >= len(s) {
+			out.WriteByte(s[i])
+			continue
+		}
+		i++
+		if b, ok := simple[s[i]]; ok {
+			out.WriteByte(b)
+			continue
+		}
+
+		// \xHH、\uHHHH、\UHHHHHHHH 和 \nnn
+		base, maxDigits, start := 16, 0, i+1
+		switch s[i] {
+		case 'x':
+			maxDigits = 2
+		case 'u':
+			maxDigits = 4
+		case 'U':
+			maxDigits = 8
+		default:
+			if s[i] >= '0' && s[i] <= '7' {
+				base, maxDigits, start = 8, 3, i
+			}
+		}
+		end := start
+		for end < len(s) && end-start < maxDigits && isDigit(s[end], base) {
+			end++
+		}
+		if maxDigits == 0 || end == start {
+			out.WriteByte('\\')
+			out.WriteByte(s[i])
+			continue
+		}
+		n, _ := strconv.ParseUint(s[start:end], base, 32)
+		if s[i] == 'u' || s[i] == 'U' {
+			out.WriteRune(rune(n))
+		} else {
+			out.WriteByte(byte(n))
+		}
+		i = end - 1
+	}
+	return 0, fmt.Errorf("unterminated $' quote in command")
+}
+
+// isDigit 判断字符是否是指定进制的数字
+func isDigit(ch byte, base int) bool {
+	if base == 8 {
+		return ch >= '0' && ch <= '7'
+	}
+	return (ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F')
+}
+
+// ToCurl 返回与请求等价的 curl 命令，包含客户端的代理、证书验证设置和 cookie jar 中的 cookie
+// 无法重复读取的请求体以 --data-binary @- 表示，从 io.Reader 添加的文件部分引用其文件名
+func (r *PreparedRequest) ToCurl() string {
+	req := r.Request
+	hasBody := r.form != nil || (req.Body != nil && req.Body != http.NoBody)
+	args := []string{"curl", shellQuote(req.URL.String())}
+	switch {
+	case req.Method == string(HEAD) && !hasBody:
+		args = append(args, "--head")
+	case req.Method == string(GET) && !hasBody, req.Method == string(POST) && hasBody:
+	default:
+		args = append(args, "-X", shellQuote(req.Method))
+	}
+
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		// multipart 的 boundary 由 curl 生成
+		if name == "Cookie" || (name == "Content-Type" && r.form != nil) {
+			continue
+		}
+		for _, value := range req.Header[name] {
+			if value == "" {
+				// curl 将 "Name:" 视为删除头部，空值需写成 "Name;"
+				args = append(args, "-H", shellQuote(name+";"))
+				continue
+			}
+			args = append(args, "-H", shellQuote(name+": "+value))
+		}
+	}
+	if req.Host != "" && req.Host != req.URL.Host {
+		args = append(args, "-H", shellQuote("Host: "+req.Host))
+	}
+	if hasBody && r.form == nil && req.Header.Get("Content-Type") == "" {
+		// curl -d 默认发送 application/x-www-form-urlencoded
+		args = append(args, "-H", "'Content-Type:'")
+	}
+
+	var cookies []string
+	if cookie := req.Header.Get("Cookie"); cookie != "" {
+		cookies = append(cookies, cookie)
+	}
+	if r.client != nil && r.client.httpClient.Jar != nil {
+		for _, cookie := range r.client.httpClient.Jar.Cookies(req.URL) {
+			cookies = append(cookies, cookie.Name+"="+cookie.Value)
+		}
+	}
+	if len(cookies) > 0 {
+		args = append(args, "-b", shellQuote(strings.Join(cookies, "; ")))
+	}
+
+	switch {
+	case r.form != nil:
+		for _, part := range r.form.parts {
+			args = append(args, part.curlArgs()...)
+		}
+	case hasBody && req.GetBody != nil:
+		body, err := req.GetBody()
+		if err == nil {
+			data, err := io.ReadAll(body)
+			body.Close()
+			if err == nil {
+				if needsANSIQuote(string(data)) {
+					args = append(args, "--data-binary", quoteANSI(string(data)))
+				} else {
+					args = append(args, "--data-raw", shellQuote(string(data)))
+				}
+				break
+			}
+		}
+		args = append(args, "--data-binary", "@-")
+	case hasBody:
+		args = append(args, "--data-binary", "@-")
+	}
+
+	if strings.Contains(req.Header.Get("Accept-Encoding"), "gzip") || strings.Contains(req.Header.Get("Accept-Encoding"), "br") {
+		args = append(args, "--compressed")
+	}
+	if r.client != nil {
+		if r.client.proxy != "" {
+			args = append(args, "-x", shellQuote(r.client.proxy))
+		}
+		if !r.client.verify {
+			args = append(args, "-k")
+		}
+	}
+	// Client 会跟随重定向
+	args = append(args, "-L")
+	return strings.Join(args, " ")
+}
+
+// curlArgs 返回部分对应的 curl -F 参数
+func (p *multipartPart) curlArgs() []string {
+	if !p.isFile {
+		return []string{"--form-string", shellQuote(p.name + "=" + string(p.value))}
+	}
+
+	var spec string
+	switch {
+	case p.path != "":
+		spec = p.name + "=@" + curlFormQuote(p.path)
+		if p.filename != filepath.Base(p.path) {
+			spec += ";filename=" + curlFormQuote(p.filename)
+		}
+	case p.reader == nil && utf8.Valid(p.value):
+		// curl 将带 filename 的普通内容作为文件部分发送
+		spec = p.name + "=" + curlFormQuote(string(p.value)) + ";filename=" + curlFormQuote(p.filename)
+	default:
+		spec = p.name + "=@" + curlFormQuote(p.filename)
+	}
+	spec += ";type=" + p.header.Get("Content-Type")
+
+	var extra []string
+	for name, values := range p.header {
+		if name == "Content-Type" {
+			continue
+		}
+		for _, value := range values {
+			extra = append(extra, name+": "+value)
+		}
+	}
+	sort.Strings(extra)
+	for _, header := range extra {
+		spec += ";headers=" + curlFormQuote(header)
+	}
+
+	if needsANSIQuote(spec) {
+		return []string{"-F", quoteANSI(spec)}
+	}
+	return []string{"-F", shellQuote(spec)}
+}
+
+// curlFormQuote 为 -F 参数中的值加上双引号
+func curlFormQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// shellQuote 使用单引号引用 shell 参数，只包含安全字符时原样返回
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_@%+=:,./-") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// needsANSIQuote 判断字符串是否包含需要用 $'...' 引用的控制字符或非 UTF-8 字节
+func needsANSIQuote(s string) bool {
+	if !utf8.ValidString(s) {
+		return true
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// quoteANSI 使用 $'...' 引用 shell 参数
+func quoteANSI(s string) string {
+	var sb strings.Builder
+	sb.WriteString("$'")
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size <= 1:
+			fmt.Fprintf(&sb, `\x%02x`, s[i])
+		case r == '\\' || r == '\'':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case r == '\n':
+			sb.WriteString(`\n`)
+		case r == '\r':
+			sb.WriteString(`\r`)
+		case r == '\t':
+			sb.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&sb, `\x%02x`, r)
+		default:
+			sb.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	sb.WriteByte('\'')
+	return sb.String()
+}
@@ -0,0 +1,246 @@
+package primp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestToCurlEmptyHeaderValue(t *testing.T) {
+	prepared, err := NewClient().Prepare(GET, "https://example.com/", RequestParams{
+		Headers: map[string]string{"User-Agent": "", "Accept": "*/*"},
+	})
+	if err != nil {
+		t.Fatalf("Prepare() error = %v", err)
+	}
+	cmd := prepared.ToCurl()
+	if !strings.Contains(cmd, "-H 'User-Agent;'") || strings.Contains(cmd, "User-Agent: ") {
+		t.Errorf("ToCurl() = %s, want empty User-Agent written as 'User-Agent;'", cmd)
+	}
+
+	parsed, err := FromCurl(cmd)
+	if err != nil {
+		t.Fatalf("FromCurl() error = %v", err)
+	}
+	if value, ok := parsed.Params.Headers["User-Agent"]; !ok || value != "" {
+		t.Errorf("FromCurl() User-Agent = %q, %v; want empty header kept", value, ok)
+	}
+	if parsed.Params.Headers["Accept"] != "*/*" {
+		t.Errorf("FromCurl() Accept = %q, want */*", parsed.Params.Headers["Accept"])
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmd     string
+		want    []string
+		wantErr bool
+	}{
+		{name: "single quotes", cmd: `curl 'a b' 'it'\''s'`, want: []string{"curl", "a b", "it's"}},
+		{name: "double quotes", cmd: `curl "a \"b\" \$c \\ \d"`, want: []string{"curl", `a "b" $c \ \d`}},
+		{name: "ansi quotes", cmd: `curl $'\x41é\n\'\101'`, want: []string{"curl", "Aé\n'A"}},
+		{name: "line continuation", cmd: "curl \\\n  -H 'A: 1' \\\r\n  https://example.com", want: []string{"curl", "-H", "A: 1", "https://example.com"}},
+		{name: "escaped space", cmd: `curl a\ b`, want: []string{"curl", "a b"}},
+		{name: "adjacent quotes", cmd: `curl 'a'"b"c`, want: []string{"curl", "abc"}},
+		{name: "unterminated single quote", cmd: `curl 'a`, wantErr: true},
+		{name: "unterminated double quote", cmd: `curl "a`, wantErr: true},
+		{name: "unterminated ansi quote", cmd: `curl $'a`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitCommand(tt.cmd)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitCommand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) && !tt.wantErr {
+				t.Errorf("splitCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromCurl(t *testing.T) {
+	dir := t.TempDir()
+	upload := filepath.Join(dir, "photo.bin")
+	if err := os.WriteFile(upload, []byte("binary"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		cmd   string
+		check func(t *testing.T, r *CurlRequest)
+	}{
+		{
+			name: "get with data",
+			cmd:  `curl -G -d 'q=go lang' -d page=2 https://example.com/search?x=1`,
+			check: func(t *testing.T, r *CurlRequest) {
+				if r.Method != GET || r.URL != "https://example.com/search?x=1&q=go lang&page=2" || r.Params.Content != nil {
+					t.Errorf("got %s %s with body %q, want GET with the data in the query", r.Method, r.URL, r.Params.Content)
+				}
+			},
+		},
+		{
+			name: "data urlencode",
+			cmd:  `curl https://example.com --data-urlencode 'q=a&b c' --data-urlencode '=x/y'`,
+			check: func(t *testing.T, r *CurlRequest) {
+				if r.Method != POST || string(r.Params.Content) != "q=a%26b+c&x%2Fy" {
+					t.Errorf("got %s with body %q, want POST q=a%%26b+c&x%%2Fy", r.Method, r.Params.Content)
+				}
+				if r.Params.Headers["Content-Type"] != "application/x-www-form-urlencoded" {
+					t.Errorf("Content-Type = %q, want curl's form default", r.Params.Headers["Content-Type"])
+				}
+			},
+		},
+		{
+			name: "form file with type",
+			cmd:  `curl https://example.com/upload -F 'title=Cat' -F 'photo=@"` + upload + `";type=image/png;filename=cat.png'`,
+			check: func(t *testing.T, r *CurlRequest) {
+				form := r.Params.Multipart
+				if r.Method != POST || form == nil || len(form.parts) != 2 {
+					t.Fatalf("got %s with form %v, want POST with two parts", r.Method, form)
+				}
+				file := form.parts[1]
+				if file.path != upload || file.filename != "cat.png" || file.header.Get("Content-Type") != "image/png" {
+					t.Errorf("file part = %s %q %q, want the upload as cat.png image/png", file.path, file.filename, file.header.Get("Content-Type"))
+				}
+			},
+		},
+		{
+			name: "form content with parameters",
+			cmd:  `curl https://example.com -F 'note="a;b";filename="n.txt";type=text/plain; charset=utf-8;headers="X-Id: 7"' -F 'plain=x;y'`,
+			check: func(t *testing.T, r *CurlRequest) {
+				form := r.Params.Multipart
+				if form == nil || len(form.parts) != 2 {
+					t.Fatalf("form = %v, want two parts", form)
+				}
+				note, plain := form.parts[0], form.parts[1]
+				if string(note.value) != "a;b" || !note.isFile || note.filename != "n.txt" {
+					t.Errorf("note part = %q file %v %q, want a;b as file n.txt", note.value, note.isFile, note.filename)
+				}
+				if note.header.Get("Content-Type") != "text/plain; charset=utf-8" || note.header.Get("X-Id") != "7" {
+					t.Errorf("note headers = %v, want type with charset and X-Id", note.header)
+				}
+				if string(plain.value) != "x;y" || plain.isFile {
+					t.Errorf("plain part = %q file %v, want field x;y", plain.value, plain.isFile)
+				}
+			},
+		},
+		{
+			name: "client options",
+			cmd:  `curl -sSL -u alice:s3cret -b 'a=1; b=2' -x http://proxy:8080 -k -m 2.5 -XPUT https://example.com`,
+			check: func(t *testing.T, r *CurlRequest) {
+				if r.Method != PUT || r.Proxy != "http://proxy:8080" || !r.Insecure {
+					t.Errorf("got %s proxy %q insecure %v, want PUT via proxy with -k", r.Method, r.Proxy, r.Insecure)
+				}
+				if r.Params.Auth == nil || *r.Params.Auth != (BasicAuth{Username: "alice", Password: "s3cret"}) {
+					t.Errorf("Auth = %v, want alice:s3cret", r.Params.Auth)
+				}
+				if !reflect.DeepEqual(r.Params.Cookies, map[string]string{"a": "1", "b": "2"}) {
+					t.Errorf("Cookies = %v, want a=1 and b=2", r.Params.Cookies)
+				}
+				if r.Params.Timeout != 2500*time.Millisecond {
+					t.Errorf("Timeout = %v, want 2.5s", r.Params.Timeout)
+				}
+			},
+		},
+		{
+			name: "head",
+			cmd:  `curl -I https://example.com`,
+			check: func(t *testing.T, r *CurlRequest) {
+				if r.Method != HEAD {
+					t.Errorf("Method = %s, want HEAD", r.Method)
+				}
+			},
+		},
+		{
+			name: "removed header and compressed",
+			cmd:  `curl https://example.com -d x -H 'Content-Type:' --compressed`,
+			check: func(t *testing.T, r *CurlRequest) {
+				if _, ok := r.Params.Headers["Content-Type"]; ok {
+					t.Errorf("Content-Type = %q, want it removed", r.Params.Headers["Content-Type"])
+				}
+				if r.Params.Headers["Accept-Encoding"] == "" {
+					t.Error("--compressed did not set Accept-Encoding")
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := FromCurl(tt.cmd)
+			if err != nil {
+				t.Fatalf("FromCurl() error = %v", err)
+			}
+			tt.check(t, r)
+		})
+	}
+}
+
+func TestFromCurlErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  string
+		want string
+	}{
+		{name: "not curl", cmd: `wget https://example.com`, want: "not a curl command"},
+		{name: "no URL", cmd: `curl -H 'A: 1'`, want: "no URL"},
+		{name: "unsupported long option", cmd: `curl --retry 3 https://example.com`, want: "unsupported curl option --retry"},
+		{name: "unsupported short option", cmd: `curl -Z https://example.com`, want: "unsupported curl option -Z"},
+		{name: "missing value", cmd: `curl https://example.com -H`, want: "requires a value"},
+		{name: "cookie file", cmd: `curl -b cookies.txt https://example.com`, want: "cookie files are not supported"},
+		{name: "data and form", cmd: `curl -d a=1 -F b=2 https://example.com`, want: "cannot mix"},
+		{name: "data from stdin", cmd: `curl -d @- https://example.com`, want: "stdin"},
+		{name: "urlencode from stdin", cmd: `curl --data-urlencode q@- https://example.com`, want: "stdin"},
+		{name: "form file from stdin", cmd: `curl -F file=@- https://example.com`, want: "stdin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FromCurl(tt.cmd)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("FromCurl() error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCurlRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		method HttpMethod
+		params RequestParams
+	}{
+		{name: "get with headers", method: GET, params: RequestParams{Headers: map[string]string{"Accept": "text/html", "X-Quote": "it's"}}},
+		{name: "form data", method: POST, params: RequestParams{DataPairs: [][2]string{{"a", "1"}, {"b", "x y"}}}},
+		{name: "json", method: PATCH, params: RequestParams{JSON: map[string]string{"name": "a\nb"}}},
+		{name: "cookies and auth", method: DELETE, params: RequestParams{Cookies: map[string]string{"sid": "abc"}, Auth: &BasicAuth{Username: "u", Password: "p"}}},
+		{name: "multipart", method: POST, params: RequestParams{Multipart: NewMultipart().AddField("title", "Cat").AddBytes("note", "note.txt", []byte("hi"))}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient()
+			prepared, err := client.Prepare(tt.method, "https://example.com/path?q=1", tt.params)
+			if err != nil {
+				t.Fatalf("Prepare() error = %v", err)
+			}
+			first := prepared.ToCurl()
+
+			parsed, err := FromCurl(first)
+			if err != nil {
+				t.Fatalf("FromCurl(%s) error = %v", first, err)
+			}
+			reprepared, err := client.Prepare(parsed.Method, parsed.URL, parsed.Params)
+			if err != nil {
+				t.Fatalf("Prepare() error = %v", err)
+			}
+			// multipart 的 boundary 每次都会重新生成，ToCurl 不输出它
+			if second := reprepared.ToCurl(); second != first {
+				t.Errorf("round trip changed the command:\n first: %s\nsecond: %s", first, second)
+			}
+		})
+	}
+}
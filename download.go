@@ -0,0 +1,482 @@
+package primp
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrChecksumMismatch 表示下载文件的校验和与期望值不一致
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// errRangeIgnored 表示服务器没有按 Range 返回部分内容，通常是文件已变化或不支持断点续传
+var errRangeIgnored = errors.New("server ignored range request")
+
+// downloadStateInterval 是下载过程中保存进度的最小间隔
+const downloadStateInterval = time.Second
+
+// DownloadOption 是配置 Client.Download 的函数类型
+type DownloadOption func(*downloadOptions)
+
+// downloadOptions 保存下载选项
+type downloadOptions struct {
+	segments int
+	hash     hash.Hash
+	checksum string
+	progress ProgressFunc
+	params   RequestParams
+}
+
+// DownloadSegments 将文件拆分为 n 个区间并行下载，服务器不支持 Range 时退化为单连接下载
+func DownloadSegments(n int) DownloadOption {
+	return func(o *downloadOptions) {
+		o.segments = n
+	}
+}
+
+// DownloadChecksum 下载完成后使用 h 计算校验和，并与十六进制的 expected 比较
+func DownloadChecksum(h hash.Hash, expected string) DownloadOption {
+	return func(o *downloadOptions) {
+		o.hash = h
+		o.checksum = strings.ToLower(strings.TrimSpace(expected))
+	}
+}
+
+// DownloadProgress 设置进度回调，已传输字节数包含之前中断时已下载的部分
+func DownloadProgress(fn ProgressFunc) DownloadOption {
+	return func(o *downloadOptions) {
+		o.progress = fn
+	}
+}
+
+// DownloadParams 设置下载请求使用的参数，例如头部、cookies 和认证
+func DownloadParams(params RequestParams) DownloadOption {
+	return func(o *downloadOptions) {
+		o.params = params
+	}
+}
+
+// downloadState 是保存在 .part.json 中的下载进度，用于断点续传
+type downloadState struct {
+	URL          string             `json:"url"`
+	ETag         string             `json:"etag,omitempty"`
+	LastModified string             `json:"last_modified,omitempty"`
+	Size         int64              `json:"size"`
+	Segments     []*downloadSegment `json:"segments"`
+}
+
+// downloadSegment 表示文件中的一个区间，End 为 -1 表示直到文件末尾
+type downloadSegment struct {
+	Start   int64 `json:"start"`
+	End     int64 `json:"end"`
+	Written int64 `json:"written"`
+}
+
+// validator 返回 If-Range 使用的校验值，弱 ETag 不能用于 If-Range
+func (s *downloadState) validator() string {
+	if s.ETag != "" && !strings.HasPrefix(s.ETag, "W/") {
+		return s.ETag
+	}
+	return s.LastModified
+}
+
+// written 返回所有区间已下载的字节数
+func (s *downloadState) written() int64 {
+	var total int64
+	for _, seg := range s.Segments {
+		total += seg.Written
+	}
+	return total
+}
+
+// Download 将 url 下载到 destPath
+// 数据先写入 destPath.part，进度保存在 destPath.part.json，中断后再次调用会使用 Range 和 If-Range 继续下载；
+// 服务器上的文件发生变化时会重新下载。全部完成并通过校验后才重命名为 destPath
+func (c *Client) Download(ctx context.Context, urlStr, destPath string, opts ...DownloadOption) error {
+	options := downloadOptions{segments: 1}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.segments < 1 {
+		options.segments = 1
+	}
+
+	d := &downloader{
+		client:    c,
+		ctx:       ctx,
+		url:       urlStr,
+		partPath:  destPath + ".part",
+		statePath: destPath + ".part.json",
+		options:   options,
+	}
+
+	err := d.run(false)
+	if errors.Is(err, errRangeIgnored) {
+		// 服务器上的文件已变化，丢弃已下载的部分重新开始
+		err = d.run(true)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := d.verify(); err != nil {
+		return err
+	}
+	if err := os.Rename(d.partPath, destPath); err != nil {
+		return fmt.Errorf("failed to rename downloaded file: %w", err)
+	}
+	os.Remove(d.statePath)
+	return nil
+}
+
+// downloader 保存一次下载的状态
+type downloader struct {
+	client    *Client
+	ctx       context.Context
+	url       string
+	partPath  string
+	statePath string
+	options   downloadOptions
+
+	mu           sync.Mutex
+	state        *downloadState
+	file         *os.File
+	lastSave     time.Time
+	lastProgress time.Time
+}
+
+// run 执行下载，restart 为 true 时忽略已有的进度
+func (d *downloader) run(restart bool) error {
+	state := d.loadState()
+	if restart || state == nil {
+		var err error
+		if state, err = d.newState(); err != nil {
+			return err
+		}
+		file, err := os.OpenFile(d.partPath, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to create file: %w", err)
+		}
+		d.file = file
+		// 预先分配文件大小，使各区间可以并行写入
+		if len(state.Segments) > 1 {
+			if err := file.Truncate(state.Size); err != nil {
+				file.Close()
+				return fmt.Errorf("failed to allocate file: %w", err)
+			}
+		}
+	} else {
+		file, err := os.OpenFile(d.partPath, os.O_RDWR, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to open file: %w", err)
+		}
+		d.file = file
+	}
+	defer d.file.Close()
+
+	d.state = state
+	if err := d.saveState(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(d.ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(state.Segments))
+	for i, seg := range state.Segments {
+		wg.Add(1)
+		go func(i int, seg *downloadSegment) {
+			defer wg.Done()
+			if errs[i] = d.fetch(ctx, seg); errs[i] != nil {
+				cancel()
+			}
+		}(i, seg)
+	}
+	wg.Wait()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.saveStateLocked(); err != nil {
+		return err
+	}
+	// 优先返回导致取消的错误
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return err
+		}
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	if d.options.progress != nil {
+		d.options.progress(state.written(), state.Size)
+	}
+	return nil
+}
+
+// loadState 读取已保存的进度，文件不存在、无法校验或 URL 不同时返回 nil
+func (d *downloader) loadState() *downloadState {
+	data, err := os.ReadFile(d.statePath)
+	if err != nil {
+		return nil
+	}
+	var state downloadState
+	if err := json.Unmarshal(data, &state); err != nil || state.URL != d.url || len(state.Segments) == 0 {
+		return nil
+	}
+	// 没有校验值时无法确认文件未变化，只能重新下载
+	if state.validator() == "" && state.written() > 0 {
+		return nil
+	}
+	info, err := os.Stat(d.partPath)
+	if err != nil {
+		return nil
+	}
+	for _, seg := range state.Segments {
+		if seg.Start+seg.Written > info.Size() {
+			return nil
+		}
+	}
+	return &state
+}
+
+// newState 创建新的下载进度，需要分段时先请求第一个字节以获取文件大小和校验值
+func (d *downloader) newState() (*downloadState, error) {
+	state := &downloadState{URL: d.url, Size: -1}
+	if d.options.segments == 1 {
+		state.Segments = []*downloadSegment{{Start: 0, End: -1}}
+		return state, nil
+	}
+
+	resp, err := d.request(d.ctx, "bytes=0-0", "")
+	if err != nil {
+		return nil, err
+	}
+	resp.Close()
+	if err := resp.RaiseForStatus(); err != nil {
+		return nil, err
+	}
+
+	state.ETag = resp.httpResp.Header.Get("ETag")
+	state.LastModified = resp.httpResp.Header.Get("Last-Modified")
+	if resp.StatusCode == http.StatusPartialContent {
+		_, state.Size = parseContentRange(resp.httpResp.Header.Get("Content-Range"))
+	}
+	if state.Size < int64(d.options.segments) {
+		state.Segments = []*downloadSegment{{Start: 0, End: -1}}
+		return state, nil
+	}
+
+	segmentSize := state.Size / int64(d.options.segments)
+	for i := 0; i < d.options.segments; i++ {
+		seg := &downloadSegment{Start: int64(i) * segmentSize, End: int64(i+1)*segmentSize - 1}
+		if i == d.options.segments-1 {
+			seg.End = state.Size - 1
+		}
+		state.Segments = append(state.Segments, seg)
+	}
+	return state, nil
+}
+
+// fetch 下载一个区间的剩余部分
+func (d *downloader) fetch(ctx context.Context, seg *downloadSegment) error {
+	d.mu.Lock()
+	offset := seg.Start + seg.Written
+	end := seg.End
+	size := d.state.Size
+	validator := d.state.validator()
+	d.mu.Unlock()
+	if (end >= 0 && offset > end) || (end < 0 && size >= 0 && offset >= size && offset > 0) {
+		return nil
+	}
+
+	rangeHeader := ""
+	if offset > 0 || end >= 0 {
+		rangeHeader = fmt.Sprintf("bytes=%d-", offset)
+		if end >= 0 {
+			rangeHeader += strconv.FormatInt(end, 10)
+		}
+	}
+	resp, err := d.request(ctx, rangeHeader, validator)
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+	if err := resp.RaiseForStatus(); err != nil {
+		return err
+	}
+
+	switch {
+	case rangeHeader == "":
+		d.mu.Lock()
+		d.state.ETag = resp.httpResp.Header.Get("ETag")
+		d.state.LastModified = resp.httpResp.Header.Get("Last-Modified")
+		d.state.Size = resp.httpResp.ContentLength
+		d.mu.Unlock()
+	case resp.StatusCode != http.StatusPartialContent:
+		return errRangeIgnored
+	default:
+		if start, _ := parseContentRange(resp.httpResp.Header.Get("Content-Range")); start != offset {
+			return errRangeIgnored
+		}
+	}
+
+	body, err := resp.Stream()
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	var reader io.Reader = body
+	if end >= 0 {
+		reader = io.LimitReader(body, end-offset+1)
+	}
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, readErr := reader.Read(buf)
+		if n > 0 {
+			if _, err := d.file.WriteAt(buf[:n], offset); err != nil {
+				return fmt.Errorf("failed to write file: %w", err)
+			}
+			offset += int64(n)
+			d.advance(seg, int64(n))
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return fmt.Errorf("failed to read response body: %w", readErr)
+		}
+	}
+	if end >= 0 && offset <= end {
+		return fmt.Errorf("failed to read response body: %w", io.ErrUnexpectedEOF)
+	}
+	return nil
+}
+
+// request 发送下载请求，validator 非空时附带 If-Range
+func (d *downloader) request(ctx context.Context, rangeHeader, validator string) (*Response, error) {
+	params := d.options.params
+	headers := make(map[string]string, len(params.Headers)+3)
+	for k, v := range params.Headers {
+		headers[k] = v
+	}
+	// 按区间下载需要未压缩的原始字节
+	headers["Accept-Encoding"] = "identity"
+	if rangeHeader != "" {
+		headers["Range"] = rangeHeader
+		if validator != "" {
+			headers["If-Range"] = validator
+		}
+	}
+	params.Headers = headers
+	params.Context = ctx
+	params.Stream = true
+	// 下载大文件不受客户端的超时和响应体大小限制
+	if params.Timeout == 0 {
+		params.Timeout = -1
+	}
+	if params.MaxBodySize == 0 {
+		params.MaxBodySize = -1
+	}
+	return d.client.Request(GET, d.url, params)
+}
+
+// advance 记录区间的下载进度，并按间隔保存进度和回调
+func (d *downloader) advance(seg *downloadSegment, n int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	seg.Written += n
+
+	now := time.Now()
+	if now.Sub(d.lastSave) >= downloadStateInterval {
+		d.saveStateLocked()
+	}
+	if d.options.progress != nil && now.Sub(d.lastProgress) >= progressInterval {
+		d.lastProgress = now
+		d.options.progress(d.state.written(), d.state.Size)
+	}
+}
+
+// saveState 保存下载进度
+func (d *downloader) saveState() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.saveStateLocked()
+}
+
+// saveStateLocked 保存下载进度，调用方需持有锁
+func (d *downloader) saveStateLocked() error {
+	d.lastSave = time.Now()
+	data, err := json.Marshal(d.state)
+	if err != nil {
+		return fmt.Errorf("failed to encode download state: %w", err)
+	}
+	if err := os.WriteFile(d.statePath, data, 0o644); err != nil {
+		return fmt.Errorf("failed to save download state: %w", err)
+	}
+	return nil
+}
+
+// verify 检查文件大小和校验和，校验失败时删除已下载的数据
+func (d *downloader) verify() error {
+	info, err := os.Stat(d.partPath)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	if d.state.Size >= 0 && info.Size() != d.state.Size {
+		return fmt.Errorf("downloaded %d bytes, expected %d", info.Size(), d.state.Size)
+	}
+	if d.options.hash == nil {
+		return nil
+	}
+
+	file, err := os.Open(d.partPath)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+	d.options.hash.Reset()
+	if _, err := io.Copy(d.options.hash, file); err != nil {
+		return fmt.Errorf("failed to compute checksum: %w", err)
+	}
+	sum := hex.EncodeToString(d.options.hash.Sum(nil))
+	if sum != d.options.checksum {
+		os.Remove(d.partPath)
+		os.Remove(d.statePath)
+		return fmt.Errorf("%w: got %s, expected %s", ErrChecksumMismatch, sum, d.options.checksum)
+	}
+	return nil
+}
+
+// parseContentRange 解析 "bytes start-end/size"，无法解析的部分返回 -1
+func parseContentRange(value string) (start, size int64) {
+	start, size = -1, -1
+	value, ok := strings.CutPrefix(strings.TrimSpace(value), "bytes ")
+	if !ok {
+		return
+	}
+	rangePart, sizePart, _ := strings.Cut(value, "/")
+	if first, _, ok := strings.Cut(rangePart, "-"); ok {
+		if n, err := strconv.ParseInt(strings.TrimSpace(first), 10, 64); err == nil {
+			start = n
+		}
+	}
+	if n, err := strconv.ParseInt(strings.TrimSpace(sizePart), 10, 64); err == nil {
+		size = n
+	}
+	return
+}
@@ -0,0 +1,206 @@
+package primp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDownloadIgnoresMaxBodySize(t *testing.T) {
+	content := strings.Repeat("0123456789", 1000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "file.bin", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+
+	dest := filepath.Join(t.TempDir(), "file.bin")
+	client := NewClient(WithMaxBodySize(100))
+	if err := client.Download(context.Background(), server.URL, dest, DownloadSegments(2)); err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	data, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if !bytes.Equal(data, []byte(content)) {
+		t.Errorf("downloaded %d bytes, want %d", len(data), len(content))
+	}
+}
+
+// downloadServer 提供可替换内容的文件，abort 为 true 时发送一半内容后断开连接
+type downloadServer struct {
+	mu      sync.Mutex
+	content string
+	etag    string
+	abort   bool
+	ranges  []string
+}
+
+func (s *downloadServer) set(content, etag string, abort bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.content, s.etag, s.abort = content, etag, abort
+}
+
+func (s *downloadServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	content, etag, abort := s.content, s.etag, s.abort
+	s.ranges = append(s.ranges, r.Header.Get("Range")+" "+r.Header.Get("If-Range"))
+	s.mu.Unlock()
+
+	w.Header().Set("ETag", etag)
+	if abort {
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.Write([]byte(content[:len(content)/2]))
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}
+	http.ServeContent(w, r, "file.bin", time.Time{}, strings.NewReader(content))
+}
+
+func (s *downloadServer) requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.ranges...)
+}
+
+// assertDownloaded 检查目标文件的内容，并确认临时文件已删除
+func assertDownloaded(t *testing.T, dest, want string) {
+	t.Helper()
+	data, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(data) != want {
+		t.Errorf("downloaded %d bytes, want %d bytes of the current content", len(data), len(want))
+	}
+	for _, path := range []string{dest + ".part", dest + ".part.json"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s still exists after download", filepath.Base(path))
+		}
+	}
+}
+
+// interruptedDownload 模拟一次中断的下载，返回目标路径
+func interruptedDownload(t *testing.T, url string) string {
+	t.Helper()
+	dest := filepath.Join(t.TempDir(), "file.bin")
+	if err := NewClient().Download(context.Background(), url, dest); err == nil {
+		t.Fatal("Download() succeeded, want the aborted body to fail")
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Fatal("destination created before the download finished")
+	}
+	info, err := os.Stat(dest + ".part")
+	if err != nil || info.Size() == 0 {
+		t.Fatalf("partial file = %v, %v; want the bytes received before the abort", info, err)
+	}
+	if _, err := os.Stat(dest + ".part.json"); err != nil {
+		t.Fatalf("state file missing: %v", err)
+	}
+	return dest
+}
+
+func TestDownloadResume(t *testing.T) {
+	content := strings.Repeat("0123456789", 20000)
+	files := &downloadServer{}
+	files.set(content, `"v1"`, true)
+	server := httptest.NewServer(files)
+	defer server.Close()
+
+	dest := interruptedDownload(t, server.URL)
+	files.set(content, `"v1"`, false)
+	if err := NewClient().Download(context.Background(), server.URL, dest); err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	assertDownloaded(t, dest, content)
+
+	requests := files.requests()
+	last := requests[len(requests)-1]
+	if !strings.HasPrefix(last, "bytes=") || strings.HasPrefix(last, "bytes=0-") || !strings.HasSuffix(last, `"v1"`) {
+		t.Errorf("resume request Range/If-Range = %q, want an open range after the received bytes with If-Range \"v1\"", last)
+	}
+}
+
+func TestDownloadRestartsWhenFileChanged(t *testing.T) {
+	files := &downloadServer{}
+	files.set(strings.Repeat("old ", 50000), `"v1"`, true)
+	server := httptest.NewServer(files)
+	defer server.Close()
+
+	dest := interruptedDownload(t, server.URL)
+	changed := strings.Repeat("new!", 40000)
+	files.set(changed, `"v2"`, false)
+	if err := NewClient().Download(context.Background(), server.URL, dest); err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	assertDownloaded(t, dest, changed)
+
+	requests := files.requests()
+	if len(requests) != 3 || !strings.HasSuffix(requests[1], `"v1"`) || requests[2] != " " {
+		t.Errorf("requests = %q, want a rejected resume followed by a full download", requests)
+	}
+}
+
+func TestDownloadSegments(t *testing.T) {
+	content := strings.Repeat("abcdefghij", 10000)
+	files := &downloadServer{}
+	files.set(content, `"v1"`, false)
+	server := httptest.NewServer(files)
+	defer server.Close()
+
+	dest := filepath.Join(t.TempDir(), "file.bin")
+	if err := NewClient().Download(context.Background(), server.URL, dest, DownloadSegments(4)); err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	assertDownloaded(t, dest, content)
+
+	ranges := map[string]bool{}
+	for _, request := range files.requests() {
+		ranges[request] = true
+	}
+	for _, want := range []string{"bytes=0-0 ", `bytes=0-24999 "v1"`, `bytes=75000-99999 "v1"`} {
+		if !ranges[want] {
+			t.Errorf("requests %q missing %q", files.requests(), want)
+		}
+	}
+	if len(ranges) != 5 {
+		t.Errorf("got %d distinct requests, want a probe and 4 segments", len(ranges))
+	}
+}
+
+func TestDownloadChecksum(t *testing.T) {
+	content := strings.Repeat("checksum", 1000)
+	files := &downloadServer{}
+	files.set(content, `"v1"`, false)
+	server := httptest.NewServer(files)
+	defer server.Close()
+	sum := sha256.Sum256([]byte(content))
+
+	dest := filepath.Join(t.TempDir(), "file.bin")
+	err := NewClient().Download(context.Background(), server.URL, dest, DownloadChecksum(sha256.New(), strings.Repeat("0", 64)))
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("Download() error = %v, want %v", err, ErrChecksumMismatch)
+	}
+	for _, path := range []string{dest, dest + ".part", dest + ".part.json"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s exists after a checksum mismatch", filepath.Base(path))
+		}
+	}
+
+	if err := NewClient().Download(context.Background(), server.URL, dest, DownloadChecksum(sha256.New(), hex.EncodeToString(sum[:]))); err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	assertDownloaded(t, dest, content)
+}
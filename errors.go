@@ -0,0 +1,193 @@
+package primp
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+)
+
+// 请求失败的错误类型，可以使用 errors.Is 判断
+var (
+	ErrTimeout           = errors.New("request timed out")
+	ErrDNS               = errors.New("DNS lookup failed")
+	ErrTLS               = errors.New("TLS handshake failed")
+	ErrProxy             = errors.New("proxy connection failed")
+	ErrTooManyRedirects  = errors.New("too many redirects")
+	ErrConnectionRefused = errors.New("connection refused")
+)
+
+// maxRedirects 是默认允许的最大重定向次数
+const maxRedirects = 10
+
+// maxHTTPErrorBody 是 HTTPError 中保留的响应体最大字节数
+const maxHTTPErrorBody = 1024
+
+// RequestError 表示发送请求时发生的错误
+// errors.Is 可以匹配 Kind 中的错误类型，errors.As 可以获取底层错误（如 *net.DNSError）
+type RequestError struct {
+	Kind   error
+	Method string
+	URL    string
+	Err    error
+}
+
+// Error 实现 error 接口
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("request failed: %s %s: %v", e.Method, e.URL, e.Err)
+}
+
+// Unwrap 返回错误类型和底层错误
+func (e *RequestError) Unwrap() []error {
+	if e.Kind == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// newRequestError 包装请求错误并识别其类型
+func newRequestError(method HttpMethod, urlStr string, err error) *RequestError {
+	return &RequestError{
+		Kind:   classifyError(err),
+		Method: string(method),
+		URL:    urlStr,
+		Err:    err,
+	}
+}
+
+// bodyReadError 包装从网络读取响应体时的错误，使读取期间的超时等同样可以用 errors.Is 判断类型
+func (r *Response) bodyReadError(err error) error {
+	if errors.Is(err, ErrBodyTooLarge) {
+		return err
+	}
+	return newRequestError(HttpMethod(r.httpResp.Request.Method), r.URL, fmt.Errorf("failed to read response body: %w", err))
+}
+
+// handshakeTrace 通过 httptrace 记录 TLS 握手是否失败
+// crypto/tls 的大部分握手错误没有导出类型，只能由此判断
+type handshakeTrace struct {
+	mu     sync.Mutex
+	failed bool
+}
+
+// withHandshakeTrace 返回附带握手记录的请求
+func (h *handshakeTrace) withHandshakeTrace(req *http.Request) *http.Request {
+	trace := &httptrace.ClientTrace{
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err != nil {
+				h.mu.Lock()
+				h.failed = true
+				h.mu.Unlock()
+			}
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
+
+// wrap 在握手失败且错误没有其他类型时将其标记为 ErrTLS
+func (h *handshakeTrace) wrap(err error) error {
+	h.mu.Lock()
+	failed := h.failed
+	h.mu.Unlock()
+	if !failed || classifyError(err) != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrTLS, err)
+}
+
+// classifyError 返回错误对应的错误类型，无法识别时返回 nil
+func classifyError(err error) error {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "proxyconnect" {
+		return ErrProxy
+	}
+	if errors.Is(err, ErrTooManyRedirects) {
+		return ErrTooManyRedirects
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return ErrDNS
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return ErrTimeout
+	}
+
+	var (
+		recordErr    tls.RecordHeaderError
+		alertErr     tls.AlertError
+		verifyErr    *tls.CertificateVerificationError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+	if errors.Is(err, ErrTLS) || errors.As(err, &recordErr) || errors.As(err, &alertErr) || errors.As(err, &verifyErr) ||
+		errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) {
+		return ErrTLS
+	}
+
+	if strings.Contains(err.Error(), "proxyconnect") || strings.Contains(err.Error(), "socks connect") {
+		return ErrProxy
+	}
+	if strings.Contains(err.Error(), "connection refused") {
+		return ErrConnectionRefused
+	}
+	return nil
+}
+
+// checkRedirect 限制重定向次数，超过时返回 ErrTooManyRedirects
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("%w: stopped after %d redirects", ErrTooManyRedirects, maxRedirects)
+	}
+	return nil
+}
+
+// HTTPError 表示状态码为 4xx 或 5xx 的响应
+type HTTPError struct {
+	StatusCode int
+	Status     string
+	URL        string
+	Headers    http.Header
+	// Body 是截断后的响应体
+	Body []byte
+}
+
+// Error 实现 error 接口
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("HTTP %s for url: %s", e.Status, e.URL)
+}
+
+// RaiseForStatus 当响应状态码为 4xx 或 5xx 时返回 *HTTPError，否则返回 nil
+func (r *Response) RaiseForStatus() error {
+	if r.StatusCode < 400 {
+		return nil
+	}
+
+	// 流模式下读取响应体会消耗数据，因此只在响应体已缓冲时附带
+	var body []byte
+	if r.raw != nil || r.content != nil {
+		if content, err := r.Content(); err == nil {
+			body = content
+		}
+	}
+	if len(body) > maxHTTPErrorBody {
+		body = body[:maxHTTPErrorBody]
+	}
+
+	return &HTTPError{
+		StatusCode: r.StatusCode,
+		Status:     r.httpResp.Status,
+		URL:        r.URL,
+		Headers:    r.httpResp.Header.Clone(),
+		Body:       body,
+	}
+}
@@ -0,0 +1,97 @@
+package primp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// closedAddr 返回一个已关闭监听的本地地址
+func closedAddr(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	return addr
+}
+
+// failingResolverClient 返回解析任何主机名都立即失败的客户端，不会发出真实的 DNS 查询
+func failingResolverClient() *Client {
+	client := NewClient()
+	client.transport().DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, _ := net.SplitHostPort(addr)
+		return nil, &net.OpError{Op: "dial", Net: network, Err: &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}}
+	}
+	return client
+}
+
+func TestRequestErrorKinds(t *testing.T) {
+	// 阻塞的服务器在请求超时前不会响应，超时不依赖服务器的处理时间
+	blocking, _, _ := blockingServer(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	}))
+	defer server.Close()
+
+	tlsServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	tlsServer.Config.ErrorLog = log.New(io.Discard, "", 0)
+	tlsServer.StartTLS()
+	defer tlsServer.Close()
+
+	// 服务器只支持 TLS 1.2，客户端只接受 TLS 1.3，握手因对方的 alert 失败，错误没有导出类型
+	oldTLSServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	oldTLSServer.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	oldTLSServer.Config.ErrorLog = log.New(io.Discard, "", 0)
+	oldTLSServer.StartTLS()
+	defer oldTLSServer.Close()
+	tls13Client := NewClient(WithVerify(false))
+	tls13Client.transport().TLSClientConfig.MinVersion = tls.VersionTLS13
+
+	refused := closedAddr(t)
+	tests := []struct {
+		name   string
+		client *Client
+		url    string
+		params RequestParams
+		want   error
+	}{
+		{name: "timeout before headers", client: NewClient(), url: blocking.URL, params: RequestParams{Timeout: 50 * time.Millisecond}, want: ErrTimeout},
+		{name: "timeout reading body", client: NewClient(), url: blocking.URL + "/body", params: RequestParams{Timeout: 50 * time.Millisecond}, want: ErrTimeout},
+		{name: "dns", client: failingResolverClient(), url: "http://does-not-exist.example/", want: ErrDNS},
+		{name: "tls unknown authority", client: NewClient(), url: tlsServer.URL, want: ErrTLS},
+		{name: "tls untyped handshake failure", client: tls13Client, url: oldTLSServer.URL, want: ErrTLS},
+		{name: "proxy", client: NewClient(WithProxy("http://" + refused)), url: server.URL, want: ErrProxy},
+		{name: "too many redirects", client: NewClient(), url: server.URL + "/loop", want: ErrTooManyRedirects},
+		{name: "connection refused", client: NewClient(), url: "http://" + refused + "/", want: ErrConnectionRefused},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.client.Get(tt.url, tt.params)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Get() error = %v, want %v", err, tt.want)
+			}
+			var requestErr *RequestError
+			if !errors.As(err, &requestErr) || requestErr.Kind != tt.want {
+				t.Errorf("Get() error = %#v, want *RequestError with Kind %v", err, tt.want)
+			}
+		})
+	}
+
+	// errors.As 可以取得底层的 *net.DNSError
+	_, err := failingResolverClient().Get("http://does-not-exist.example/")
+	var dnsErr *net.DNSError
+	if !errors.As(err, &dnsErr) || dnsErr.Name != "does-not-exist.example" {
+		t.Errorf("Get() error = %v, want a *net.DNSError for the host", err)
+	}
+}
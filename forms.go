@@ -0,0 +1,442 @@
+package primp
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Form 表示页面中的 HTML 表单，可以修改字段后通过原 Client 提交
+type Form struct {
+	ID      string
+	Name    string
+	Action  string
+	Method  HttpMethod
+	Enctype string
+	Fields  []*FormField
+
+	client  *Client
+	pageURL string
+}
+
+// FormField 表示表单中的一个控件
+type FormField struct {
+	Name     string
+	Type     string
+	Value    string
+	Checked  bool
+	Disabled bool
+	Options  []string
+	// Multiple 表示 <select multiple>，选中的值保存在 Selected 中，Value 为第一个选中的值
+	Multiple bool
+	Selected []string
+}
+
+// Forms 返回页面中所有表单，字段包含默认值和隐藏字段（如 CSRF 令牌）
+func (r *Response) Forms() ([]*Form, error) {
+	doc, err := r.document()
+	if err != nil {
+		return nil, err
+	}
+
+	forms := extractForms(doc, r.URL)
+	for _, form := range forms {
+		form.client = r.client
+	}
+	return forms, nil
+}
+
+// extractForms 从文档树中解析表单
+func extractForms(doc *html.Node, pageAddr string) []*Form {
+	base := documentBaseURL(doc, pageAddr)
+	var forms []*Form
+	byID := make(map[string]*Form)
+
+	// 第一遍解析表单元素
+	var collectForms func(*html.Node)
+	collectForms = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Form {
+			form := newForm(n, base, pageAddr)
+			forms = append(forms, form)
+			if form.ID != "" {
+				byID[form.ID] = form
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			collectForms(child)
+		}
+	}
+	collectForms(doc)
+
+	// 第二遍收集控件，支持 form 属性关联到其他位置的表单
+	index := -1
+	var collectFields func(*html.Node, *Form)
+	collectFields = func(n *html.Node, current *Form) {
+		if n.Type == html.ElementNode {
+			if n.DataAtom == atom.Form {
+				index++
+				current = forms[index]
+			}
+			owner := current
+			if id, ok := getAttr(n, "form"); ok {
+				owner = byID[id]
+			}
+			if owner != nil {
+				if field := newFormField(n); field != nil {
+					owner.Fields = append(owner.Fields, field)
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			collectFields(child, current)
+		}
+	}
+	collectFields(doc, nil)
+
+	return forms
+}
+
+// newForm 根据 <form> 元素的属性创建表单
+func newForm(n *html.Node, base *url.URL, pageAddr string) *Form {
+	id, _ := getAttr(n, "id")
+	name, _ := getAttr(n, "name")
+	action, _ := getAttr(n, "action")
+	method, _ := getAttr(n, "method")
+	enctype, _ := getAttr(n, "enctype")
+
+	form := &Form{
+		ID:      id,
+		Name:    name,
+		Method:  GET,
+		Enctype: "application/x-www-form-urlencoded",
+		pageURL: pageAddr,
+	}
+	if strings.TrimSpace(action) == "" {
+		form.Action = pageURL(pageAddr)
+	} else {
+		form.Action = resolveURL(base, action)
+	}
+	if strings.EqualFold(strings.TrimSpace(method), "post") {
+		form.Method = POST
+	}
+	switch strings.ToLower(strings.TrimSpace(enctype)) {
+	case "multipart/form-data":
+		form.Enctype = "multipart/form-data"
+	case "text/plain":
+		form.Enctype = "text/plain"
+	}
+	return form
+}
+
+// newFormField 将控件元素解析为表单字段，非控件元素返回 nil
+func newFormField(n *html.Node) *FormField {
+	name, _ := getAttr(n, "name")
+	_, disabled := getAttr(n, "disabled")
+
+	switch n.DataAtom {
+	case atom.Input:
+		typ, _ := getAttr(n, "type")
+		typ = strings.ToLower(strings.TrimSpace(typ))
+		if typ == "" {
+			typ = "text"
+		}
+		value, hasValue := getAttr(n, "value")
+		if !hasValue && (typ == "checkbox" || typ == "radio") {
+			value = "on"
+		}
+		_, checked := getAttr(n, "checked")
+		return &FormField{Name: name, Type: typ, Value: value, Checked: checked, Disabled: disabled}
+	case atom.Button:
+		typ, _ := getAttr(n, "type")
+		typ = strings.ToLower(strings.TrimSpace(typ))
+		if typ == "" {
+			typ = "submit"
+		}
+		value, _ := getAttr(n, "value")
+		return &FormField{Name: name, Type: typ, Value: value, Disabled: disabled}
+	case atom.Textarea:
+		return &FormField{Name: name, Type: "textarea", Value: strings.TrimPrefix(textContent(n), "\n"), Disabled: disabled}
+	case atom.Select:
+		_, multiple := getAttr(n, "multiple")
+		field := &FormField{Name: name, Type: "select", Disabled: disabled, Multiple: multiple}
+		selected := false
+		var walk func(*html.Node, bool)
+		walk = func(node *html.Node, groupDisabled bool) {
+			for child := node.FirstChild; child != nil; child = child.NextSibling {
+				if child.Type != html.ElementNode {
+					continue
+				}
+				if child.DataAtom == atom.Optgroup {
+					_, disabled := getAttr(child, "disabled")
+					walk(child, groupDisabled || disabled)
+					continue
+				}
+				if child.DataAtom != atom.Option {
+					continue
+				}
+				// 禁用的选项既不能选择也不会提交
+				if _, disabled := getAttr(child, "disabled"); disabled || groupDisabled {
+					continue
+				}
+				value, ok := getAttr(child, "value")
+				if !ok {
+					value = innerText(child)
+				}
+				field.Options = append(field.Options, value)
+				if _, ok := getAttr(child, "selected"); ok {
+					// 单选时以最后一个 selected 的选项为准
+					if multiple {
+						field.Selected = append(field.Selected, value)
+					}
+					field.Value = value
+					selected = true
+				}
+			}
+		}
+		walk(n, false)
+		if multiple {
+			field.Value = ""
+			if len(field.Selected) > 0 {
+				field.Value = field.Selected[0]
+			}
+		} else if !selected && len(field.Options) > 0 {
+			// 下拉框没有选中项时浏览器会选中第一个选项，多选框则不提交任何值
+			field.Value = field.Options[0]
+		}
+		return field
+	}
+	return nil
+}
+
+// Get 返回指定名称字段的当前值
+func (f *Form) Get(name string) string {
+	for _, field := range f.Fields {
+		if field.Name == name && (field.Type != "checkbox" && field.Type != "radio" || field.Checked) {
+			return field.Value
+		}
+	}
+	return ""
+}
+
+// Set 设置字段的值；复选框和单选框会选中值相同的选项，多选下拉框会追加选中的值，字段不存在时添加隐藏字段
+func (f *Form) Set(name, value string) {
+	found := false
+	for _, field := range f.Fields {
+		if field.Name != name {
+			continue
+		}
+		switch field.Type {
+		case "checkbox", "radio":
+			if field.Value == value {
+				field.Checked = true
+				found = true
+			} else if field.Type == "radio" {
+				field.Checked = false
+			}
+		case "select":
+			if found {
+				continue
+			}
+			found = true
+			if !field.Multiple {
+				field.Value = value
+				continue
+			}
+			// 多选框与复选框一样追加选中的值
+			if !slices.Contains(field.Selected, value) {
+				field.Selected = append(field.Selected, value)
+			}
+			field.Value = field.Selected[0]
+		case "submit", "button", "reset", "image":
+		default:
+			if !found {
+				field.Value = value
+				found = true
+			}
+		}
+	}
+	if !found {
+		f.Add(name, value)
+	}
+}
+
+// Add 添加一个隐藏字段
+func (f *Form) Add(name, value string) {
+	f.Fields = append(f.Fields, &FormField{Name: name, Type: "hidden", Value: value})
+}
+
+// Pairs 按文档顺序返回将要提交的字段名和值，不包含提交按钮
+func (f *Form) Pairs() [][2]string {
+	return f.pairs(nil)
+}
+
+// pairs 按文档顺序返回字段名和值，submitter 非空时在其位置加入按钮的值
+func (f *Form) pairs(submitter *FormField) [][2]string {
+	var pairs [][2]string
+	for _, field := range f.Fields {
+		if field == submitter && field.Type == "image" {
+			// 图片按钮提交点击坐标
+			prefix := ""
+			if field.Name != "" {
+				prefix = field.Name + "."
+			}
+			pairs = append(pairs, [2]string{prefix + "x", "0"}, [2]string{prefix + "y", "0"})
+			continue
+		}
+		if field.Name == "" || field.Disabled {
+			continue
+		}
+		switch field.Type {
+		case "submit":
+			if field != submitter {
+				continue
+			}
+		case "button", "reset", "image", "file":
+			continue
+		case "checkbox", "radio":
+			if !field.Checked {
+				continue
+			}
+		case "select":
+			if field.Multiple {
+				for _, value := range field.Selected {
+					pairs = append(pairs, [2]string{field.Name, value})
+				}
+				continue
+			}
+			if len(field.Options) == 0 {
+				continue
+			}
+		}
+		pairs = append(pairs, [2]string{field.Name, field.Value})
+	}
+	return pairs
+}
+
+// Values 返回将要提交的字段
+func (f *Form) Values() url.Values {
+	values := url.Values{}
+	for _, pair := range f.Pairs() {
+		values.Add(pair[0], pair[1])
+	}
+	return values
+}
+
+// Submitter 返回名称为 name 的提交按钮，name 为空时返回第一个提交按钮，不存在时返回 nil
+func (f *Form) Submitter(name string) *FormField {
+	for _, field := range f.Fields {
+		if (field.Type == "submit" || field.Type == "image") && !field.Disabled && (name == "" || field.Name == name) {
+			return field
+		}
+	}
+	return nil
+}
+
+// Submit 使用获取页面的 Client 提交表单，共享其 cookies 并以表单所在页面作为 Referer
+// 与脚本调用 form.submit() 一样不包含任何提交按钮的值，模拟点击按钮请使用 SubmitWith
+func (f *Form) Submit(params ...RequestParams) (*Response, error) {
+	return f.submit(nil, params)
+}
+
+// SubmitWith 模拟点击 submitter 提交表单，按钮的 name=value 会和浏览器一样加入提交的数据
+// submitter 为 nil 时与 Submit 相同
+func (f *Form) SubmitWith(submitter *FormField, params ...RequestParams) (*Response, error) {
+	if submitter != nil {
+		if submitter.Type != "submit" && submitter.Type != "image" {
+			return nil, fmt.Errorf("form field %q is not a submit button", submitter.Name)
+		}
+		owned := false
+		for _, field := range f.Fields {
+			owned = owned || field == submitter
+		}
+		if !owned {
+			return nil, fmt.Errorf("submit button %q does not belong to the form", submitter.Name)
+		}
+	}
+	return f.submit(submitter, params)
+}
+
+// submit 提交表单，submitter 为被点击的提交按钮
+func (f *Form) submit(submitter *FormField, params []RequestParams) (*Response, error) {
+	if f.client == nil {
+		return nil, fmt.Errorf("form is not associated with a client")
+	}
+
+	var reqParams RequestParams
+	if len(params) > 0 {
+		reqParams = params[0]
+	}
+	headers := map[string]string{"Referer": pageURL(f.pageURL)}
+	for k, v := range reqParams.Headers {
+		headers[k] = v
+	}
+	reqParams.Headers = headers
+
+	action := f.Action
+	if f.Method == GET {
+		u, err := url.Parse(action)
+		if err != nil {
+			return nil, fmt.Errorf("invalid form action: %w", err)
+		}
+		u.RawQuery = encodePairs(f.pairs(submitter))
+		u.Fragment = ""
+		return f.client.Request(GET, u.String(), reqParams)
+	}
+
+	if origin, err := url.Parse(f.pageURL); err == nil && origin.Host != "" {
+		reqParams.Headers["Origin"] = origin.Scheme + "://" + origin.Host
+	}
+	body, contentType, err := f.encode(f.pairs(submitter))
+	if err != nil {
+		return nil, err
+	}
+	reqParams.Content = body
+	reqParams.Headers["Content-Type"] = contentType
+	return f.client.Request(f.Method, action, reqParams)
+}
+
+// encode 按表单的 enctype 编码请求体
+func (f *Form) encode(pairs [][2]string) ([]byte, string, error) {
+	switch f.Enctype {
+	case "multipart/form-data":
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		for _, pair := range pairs {
+			if err := w.WriteField(pair[0], pair[1]); err != nil {
+				return nil, "", fmt.Errorf("failed to write form field: %w", err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			return nil, "", fmt.Errorf("failed to close multipart writer: %w", err)
+		}
+		return buf.Bytes(), w.FormDataContentType(), nil
+	case "text/plain":
+		var sb strings.Builder
+		for _, pair := range pairs {
+			sb.WriteString(pair[0] + "=" + pair[1] + "\r\n")
+		}
+		return []byte(sb.String()), "text/plain", nil
+	default:
+		return []byte(encodePairs(pairs)), "application/x-www-form-urlencoded", nil
+	}
+}
+
+// encodePairs 按原顺序进行 URL 编码
+func encodePairs(pairs [][2]string) string {
+	var sb strings.Builder
+	for i, pair := range pairs {
+		if i > 0 {
+			sb.WriteByte('&')
+		}
+		sb.WriteString(url.QueryEscape(pair[0]))
+		sb.WriteByte('=')
+		sb.WriteString(url.QueryEscape(pair[1]))
+	}
+	return sb.String()
+}
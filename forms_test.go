@@ -0,0 +1,236 @@
+package primp
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// formPage 是表单测试使用的页面
+const formPage = `<html><head><base href="/app/"></head><body>
+<form id="search" action="search?lang=en#results">
+  <input name="q" value="go">
+  <select name="sort"><option value="new">Newest</option><option selected>Oldest</option></select>
+  <select name="empty"></select>
+  <input type="checkbox" name="exact" checked>
+  <input type="checkbox" name="safe" value="1">
+  <input type="radio" name="size" value="s">
+  <input type="radio" name="size" value="m" checked>
+  <input name="disabled" value="x" disabled>
+  <button name="go" value="search">Search</button>
+</form>
+<form method="post" action="/login" id="login">
+  <input type="hidden" name="csrf" value="token123">
+  <input name="user">
+  <textarea name="note">
+hello</textarea>
+  <button type="submit" name="action" value="login">Log in</button>
+  <button type="submit" name="action" value="register">Register</button>
+  <input type="image" name="map" src="map.png">
+</form>
+<input form="login" name="remember" type="checkbox" value="yes" checked>
+</body></html>`
+
+// formServer 返回表单页面，并将其他请求的方法、路径、查询和请求体写入 requests
+func formServer(t *testing.T, requests chan<- string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/page" {
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(formPage))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		requests <- r.Method + " " + r.URL.RequestURI() + " " + string(body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// pageForms 获取页面中的表单
+func pageForms(t *testing.T, server *httptest.Server) []*Form {
+	t.Helper()
+	resp, err := NewClient().Get(server.URL + "/page")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	forms, err := resp.Forms()
+	if err != nil {
+		t.Fatalf("Forms() error = %v", err)
+	}
+	if len(forms) != 2 {
+		t.Fatalf("Forms() returned %d forms, want 2", len(forms))
+	}
+	return forms
+}
+
+func TestFormDefaults(t *testing.T) {
+	server := formServer(t, nil)
+	forms := pageForms(t, server)
+	search, login := forms[0], forms[1]
+
+	if search.Method != GET || search.Action != server.URL+"/app/search?lang=en#results" {
+		t.Errorf("search form = %s %s, want GET resolved against <base href>", search.Method, search.Action)
+	}
+	wantSearch := [][2]string{{"q", "go"}, {"sort", "Oldest"}, {"exact", "on"}, {"size", "m"}}
+	if got := search.Pairs(); !reflect.DeepEqual(got, wantSearch) {
+		t.Errorf("search Pairs() = %q, want %q", got, wantSearch)
+	}
+
+	if login.Method != POST || login.Action != server.URL+"/login" {
+		t.Errorf("login form = %s %s, want POST /login", login.Method, login.Action)
+	}
+	wantLogin := [][2]string{{"csrf", "token123"}, {"user", ""}, {"note", "hello"}, {"remember", "yes"}}
+	if got := login.Pairs(); !reflect.DeepEqual(got, wantLogin) {
+		t.Errorf("login Pairs() = %q, want %q", got, wantLogin)
+	}
+
+	search.Set("size", "s")
+	search.Set("safe", "1")
+	search.Set("extra", "added")
+	if search.Get("size") != "s" || search.Get("safe") != "1" || search.Get("extra") != "added" {
+		t.Errorf("after Set() values = %v, want size=s, safe=1 and extra=added", search.Values())
+	}
+}
+
+func TestFormSubmit(t *testing.T) {
+	byValue := func(value string) func(*Form) *FormField {
+		return func(form *Form) *FormField {
+			for _, field := range form.Fields {
+				if field.Type == "submit" && field.Value == value {
+					return field
+				}
+			}
+			return nil
+		}
+	}
+	byName := func(name string) func(*Form) *FormField {
+		return func(form *Form) *FormField {
+			return form.Submitter(name)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		form      int
+		submitter func(*Form) *FormField
+		want      string
+	}{
+		{name: "get without submitter", form: 0, want: "GET /app/search?q=golang&sort=Oldest&exact=on&size=m "},
+		{name: "get with submitter", form: 0, submitter: byName("go"), want: "GET /app/search?q=golang&sort=Oldest&exact=on&size=m&go=search "},
+		{name: "post without submitter", form: 1, want: "POST /login csrf=token123&user=alice&note=hello&remember=yes"},
+		{name: "post with first button", form: 1, submitter: byName(""), want: "POST /login csrf=token123&user=alice&note=hello&action=login&remember=yes"},
+		{name: "post with second button", form: 1, submitter: byValue("register"), want: "POST /login csrf=token123&user=alice&note=hello&action=register&remember=yes"},
+		{name: "post with image button", form: 1, submitter: byName("map"), want: "POST /login csrf=token123&user=alice&note=hello&map.x=0&map.y=0&remember=yes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := make(chan string, 1)
+			server := formServer(t, requests)
+			form := pageForms(t, server)[tt.form]
+			if form.Method == GET {
+				form.Set("q", "golang")
+			} else {
+				form.Set("user", "alice")
+			}
+
+			var submitter *FormField
+			if tt.submitter != nil {
+				if submitter = tt.submitter(form); submitter == nil {
+					t.Fatal("submit button not found")
+				}
+			}
+			if _, err := form.SubmitWith(submitter); err != nil {
+				t.Fatalf("SubmitWith() error = %v", err)
+			}
+			if got := <-requests; got != tt.want {
+				t.Errorf("server got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormSubmitWithForeignButton(t *testing.T) {
+	server := formServer(t, nil)
+	forms := pageForms(t, server)
+	if _, err := forms[1].SubmitWith(forms[0].Submitter("")); err == nil {
+		t.Error("SubmitWith() accepted a button from another form")
+	}
+	if _, err := forms[1].SubmitWith(forms[1].Fields[0]); err == nil {
+		t.Error("SubmitWith() accepted a hidden field as submitter")
+	}
+}
+
+func TestFormSelectOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		markup string
+		want   [][2]string
+	}{
+		{
+			name:   "single defaults to first enabled option",
+			markup: `<select name="s"><option disabled>x</option><option>a</option><option>b</option></select>`,
+			want:   [][2]string{{"s", "a"}},
+		},
+		{
+			name:   "single keeps the last selected option",
+			markup: `<select name="s"><option selected>a</option><option selected>b</option><option>c</option></select>`,
+			want:   [][2]string{{"s", "b"}},
+		},
+		{
+			name:   "multiple submits every selected option",
+			markup: `<select name="m" multiple><option selected>a</option><option>b</option><optgroup label="g"><option value="c" selected>C</option></optgroup></select>`,
+			want:   [][2]string{{"m", "a"}, {"m", "c"}},
+		},
+		{
+			name:   "multiple without selection submits nothing",
+			markup: `<select name="m" multiple><option>a</option><option>b</option></select>`,
+			want:   nil,
+		},
+		{
+			name:   "disabled options are skipped",
+			markup: `<select name="m" multiple><option selected disabled>a</option><optgroup disabled><option selected>b</option></optgroup><option selected>c</option></select>`,
+			want:   [][2]string{{"m", "c"}},
+		},
+		{
+			name:   "all options disabled",
+			markup: `<select name="s"><option disabled selected>a</option></select>`,
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader("<form>" + tt.markup + "</form>"))
+			if err != nil {
+				t.Fatalf("html.Parse() error = %v", err)
+			}
+			forms := extractForms(doc, "https://example.com/")
+			if got := forms[0].Pairs(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Pairs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormSetMultipleSelect(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<form><select name="m" multiple><option>a</option><option selected>b</option><option>c</option></select></form>`))
+	if err != nil {
+		t.Fatalf("html.Parse() error = %v", err)
+	}
+	form := extractForms(doc, "https://example.com/")[0]
+	form.Set("m", "c")
+	form.Set("m", "b")
+	want := [][2]string{{"m", "b"}, {"m", "c"}}
+	if got := form.Pairs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Pairs() = %q, want %q", got, want)
+	}
+	if got := form.Get("m"); got != "b" {
+		t.Errorf("Get() = %q, want %q", got, "b")
+	}
+}
@@ -0,0 +1,535 @@
+package primp
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// defaultHARBodySize 是 HAR 中每个请求体和响应体默认保留的最大字节数
+const defaultHARBodySize = 1 << 20
+
+// harTimeFormat 是固定宽度的 ISO 8601 时间格式，保证按字符串排序即按时间排序
+const harTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// HAR 表示 HAR 1.2 文档
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+// HARLog 是 HAR 文档的根对象
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+// HARCreator 表示生成 HAR 的程序
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry 表示一次请求和响应，重定向的每一跳各自对应一个条目
+type HAREntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+// HARRequest 表示 HAR 中的请求
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+// HARResponse 表示 HAR 中的响应
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+// HARNameValue 表示头部或查询参数
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARCookie 表示请求或响应中的 cookie
+type HARCookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	Expires  string `json:"expires,omitempty"`
+	HTTPOnly bool   `json:"httpOnly,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+}
+
+// HARPostData 表示请求体
+type HARPostData struct {
+	MimeType string         `json:"mimeType"`
+	Params   []HARNameValue `json:"params,omitempty"`
+	Text     string         `json:"text"`
+	Comment  string         `json:"comment,omitempty"`
+}
+
+// HARContent 表示解压后的响应内容，二进制内容使用 base64 编码
+// Truncated 和 ContentEncoding 是 HAR 允许的自定义字段，分别表示 Text 只是开头部分，以及 Text 仍按该编码压缩（解压失败时）
+type HARContent struct {
+	Size            int64  `json:"size"`
+	Compression     int64  `json:"compression,omitempty"`
+	MimeType        string `json:"mimeType"`
+	Text            string `json:"text,omitempty"`
+	Encoding        string `json:"encoding,omitempty"`
+	Comment         string `json:"comment,omitempty"`
+	Truncated       bool   `json:"_truncated,omitempty"`
+	ContentEncoding string `json:"_contentEncoding,omitempty"`
+}
+
+// HARTimings 表示各阶段耗时（毫秒），不适用的阶段为 -1
+type HARTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// HARRecorder 记录经过 Client 的所有请求和响应，可以导出为 HAR 1.2 文件
+type HARRecorder struct {
+	mu          sync.Mutex
+	entries     []HAREntry
+	maxBodySize int64
+}
+
+// NewHARRecorder 创建 HAR 记录器，maxBodySize 限制每个请求体和响应体保留的字节数，默认 1MB
+func NewHARRecorder(maxBodySize ...int64) *HARRecorder {
+	size := int64(defaultHARBodySize)
+	if len(maxBodySize) > 0 {
+		size = maxBodySize[0]
+	}
+	return &HARRecorder{maxBodySize: size}
+}
+
+// HAR 返回已记录的内容，条目按开始时间排序
+func (r *HARRecorder) HAR() *HAR {
+	r.mu.Lock()
+	entries := append([]HAREntry(nil), r.entries...)
+	r.mu.Unlock()
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartedDateTime < entries[j].StartedDateTime
+	})
+	return &HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: "go-primp", Version: "1.0"},
+		Entries: entries,
+	}}
+}
+
+// Save 将已记录的内容写入 HAR 文件
+func (r *HARRecorder) Save(path string) error {
+	data, err := json.MarshalIndent(r.HAR(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode HAR: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write HAR file: %w", err)
+	}
+	return nil
+}
+
+// Reset 清空已记录的内容
+func (r *HARRecorder) Reset() {
+	r.mu.Lock()
+	r.entries = nil
+	r.mu.Unlock()
+}
+
+// add 添加一个完成的条目
+func (r *HARRecorder) add(entry HAREntry) {
+	r.mu.Lock()
+	r.entries = append(r.entries, entry)
+	r.mu.Unlock()
+}
+
+// transport 返回记录每一跳请求的 RoundTripper
+func (r *HARRecorder) transport(base http.RoundTripper) http.RoundTripper {
+	return &harTransport{recorder: r, base: base}
+}
+
+// harTransport 在传输层记录请求，能看到 cookie jar 添加的头部和每次重定向
+// 请求头取自 httptrace 报告的实际写出的字段，按写出顺序排列（HTTP/2 包含伪头部）
+type harTransport struct {
+	recorder *HARRecorder
+	base     http.RoundTripper
+}
+
+// RoundTrip 实现 http.RoundTripper 接口
+func (t *harTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := &harExchange{recorder: t.recorder, start: time.Now()}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), rec.clientTrace()))
+	if req.Body != nil && req.Body != http.NoBody {
+		rec.requestBody = &captureBody{ReadCloser: req.Body, limit: t.recorder.maxBodySize}
+		req.Body = rec.requestBody
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	rec.mu.Lock()
+	if rec.firstByte.IsZero() {
+		rec.firstByte = time.Now()
+	}
+	rec.mu.Unlock()
+	if err != nil {
+		rec.finish(req, nil, err)
+		return nil, err
+	}
+
+	body := &captureBody{ReadCloser: resp.Body, limit: t.recorder.maxBodySize}
+	body.onClose = func() {
+		rec.finish(req, resp, nil)
+	}
+	rec.responseBody = body
+	resp.Body = body
+	return resp, nil
+}
+
+// harExchange 收集一次请求的数据和各阶段时间
+type harExchange struct {
+	recorder *HARRecorder
+	once     sync.Once
+
+	start time.Time
+
+	// mu 保护以下字段，httptrace 回调可能在传输层的其他协程中执行（如 HTTP/2 的读写协程、并行拨号）
+	mu                                           sync.Mutex
+	wroteHeaders                                 []HARNameValue
+	dnsStart, dnsDone, connectStart, connectDone time.Time
+	tlsStart, tlsDone, gotConn, wroteRequest     time.Time
+	firstByte                                    time.Time
+	serverIP                                     string
+
+	requestBody  *captureBody
+	responseBody *captureBody
+}
+
+// clientTrace 返回记录各阶段时间的 httptrace.ClientTrace
+func (e *harExchange) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { e.mark(&e.dnsStart) },
+		DNSDone:           func(httptrace.DNSDoneInfo) { e.mark(&e.dnsDone) },
+		ConnectStart:      func(string, string) { e.mark(&e.connectStart) },
+		ConnectDone:       func(string, string, error) { e.mark(&e.connectDone) },
+		TLSHandshakeStart: func() { e.mark(&e.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { e.mark(&e.tlsDone) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			now := time.Now()
+			host, _, err := net.SplitHostPort(info.Conn.RemoteAddr().String())
+			e.mu.Lock()
+			defer e.mu.Unlock()
+			e.gotConn = now
+			// 换用新连接重试时头部会重新写出
+			e.wroteHeaders = nil
+			if err == nil {
+				e.serverIP = host
+			}
+		},
+		WroteHeaderField: func(key string, values []string) {
+			e.mu.Lock()
+			defer e.mu.Unlock()
+			for _, value := range values {
+				e.wroteHeaders = append(e.wroteHeaders, HARNameValue{Name: key, Value: value})
+			}
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { e.mark(&e.wroteRequest) },
+		GotFirstResponseByte: func() { e.mark(&e.firstByte) },
+	}
+}
+
+// mark 在持有 mu 时将 field 设为当前时间
+func (e *harExchange) mark(field *time.Time) {
+	now := time.Now()
+	e.mu.Lock()
+	*field = now
+	e.mu.Unlock()
+}
+
+// finish 生成条目并交给记录器，只执行一次
+func (e *harExchange) finish(req *http.Request, resp *http.Response, err error) {
+	e.once.Do(func() {
+		end := time.Now()
+		entry := HAREntry{
+			StartedDateTime: e.start.Format(harTimeFormat),
+			Time:            milliseconds(e.start, end),
+			Request:         harRequest(req, e.requestBody),
+		}
+		e.mu.Lock()
+		entry.Timings = e.timings(end)
+		entry.ServerIPAddress = e.serverIP
+		if len(e.wroteHeaders) > 0 {
+			entry.Request.Headers = e.wroteHeaders
+		}
+		e.mu.Unlock()
+		if err != nil {
+			entry.Comment = err.Error()
+			entry.Response = HARResponse{Cookies: []HARCookie{}, Headers: []HARNameValue{}, HeadersSize: -1, BodySize: -1}
+		} else {
+			entry.Response = harResponse(resp, e.responseBody)
+			entry.Request.HTTPVersion = resp.Proto
+		}
+		e.recorder.add(entry)
+	})
+}
+
+// timings 计算 HAR 各阶段耗时，调用方需持有 mu
+func (e *harExchange) timings(end time.Time) HARTimings {
+	timings := HARTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1}
+	if !e.dnsStart.IsZero() && !e.dnsDone.IsZero() {
+		timings.DNS = milliseconds(e.dnsStart, e.dnsDone)
+	}
+	connectEnd := e.connectDone
+	if !e.tlsDone.IsZero() {
+		connectEnd = e.tlsDone
+		timings.SSL = milliseconds(e.tlsStart, e.tlsDone)
+	}
+	if !e.connectStart.IsZero() && !connectEnd.IsZero() {
+		timings.Connect = milliseconds(e.connectStart, connectEnd)
+	}
+
+	// 拿到连接之前未计入 DNS 和建立连接的时间视为等待
+	if !e.gotConn.IsZero() {
+		blocked := milliseconds(e.start, e.gotConn)
+		if timings.DNS > 0 {
+			blocked -= timings.DNS
+		}
+		if timings.Connect > 0 {
+			blocked -= timings.Connect
+		}
+		timings.Blocked = max(blocked, 0)
+		if !e.wroteRequest.IsZero() {
+			timings.Send = milliseconds(e.gotConn, e.wroteRequest)
+			timings.Wait = milliseconds(e.wroteRequest, e.firstByte)
+		}
+	}
+	timings.Receive = milliseconds(e.firstByte, end)
+	return timings
+}
+
+// harRequest 将请求转换为 HAR 格式，传输层报告了实际写出的头部时由 finish 替换 Headers
+func harRequest(req *http.Request, body *captureBody) HARRequest {
+	request := HARRequest{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		HTTPVersion: req.Proto,
+		Cookies:     []HARCookie{},
+		Headers:     sortedHeaders(requestHeader(req)),
+		QueryString: queryPairs(req.URL.RawQuery),
+		HeadersSize: -1,
+	}
+	for _, cookie := range req.Cookies() {
+		request.Cookies = append(request.Cookies, HARCookie{Name: cookie.Name, Value: cookie.Value})
+	}
+
+	if body == nil {
+		return request
+	}
+	request.BodySize = body.read
+	mimeType := req.Header.Get("Content-Type")
+	postData := &HARPostData{MimeType: mimeType}
+	data := body.captured.Bytes()
+	if utf8.Valid(data) {
+		postData.Text = string(data)
+		if strings.HasPrefix(mimeType, "application/x-www-form-urlencoded") {
+			postData.Params = queryPairs(postData.Text)
+		}
+	} else {
+		postData.Comment = "binary body omitted"
+	}
+	if body.truncated() {
+		postData.Comment = fmt.Sprintf("truncated to %d of %d bytes", len(data), body.read)
+	}
+	request.PostData = postData
+	return request
+}
+
+// harResponse 将响应转换为 HAR 格式，内容按 Content-Encoding 解压
+func harResponse(resp *http.Response, body *captureBody) HARResponse {
+	response := HARResponse{
+		Status:      resp.StatusCode,
+		StatusText:  strings.TrimSpace(strings.TrimPrefix(resp.Status, strconv.Itoa(resp.StatusCode))),
+		HTTPVersion: resp.Proto,
+		Cookies:     []HARCookie{},
+		Headers:     sortedHeaders(resp.Header),
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    body.read,
+		Content:     HARContent{Size: body.read, MimeType: resp.Header.Get("Content-Type")},
+	}
+	for _, cookie := range resp.Cookies() {
+		harCookie := HARCookie{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Path:     cookie.Path,
+			Domain:   cookie.Domain,
+			HTTPOnly: cookie.HttpOnly,
+			Secure:   cookie.Secure,
+		}
+		if !cookie.Expires.IsZero() {
+			harCookie.Expires = cookie.Expires.Format(time.RFC3339)
+		}
+		response.Cookies = append(response.Cookies, harCookie)
+	}
+
+	data := body.captured.Bytes()
+	truncated := body.truncated()
+	if truncated {
+		response.Content.Truncated = true
+		response.Content.Comment = fmt.Sprintf("truncated to %d of %d bytes", len(data), body.read)
+	}
+	if encoding := resp.Header.Get("Content-Encoding"); encoding != "" && !resp.Uncompressed {
+		// 截断的数据无法完整解压，只保留能解出的开头部分
+		decoder := newDecodedBody(bytes.NewReader(data), encoding)
+		decoded, err := io.ReadAll(decoder)
+		decoder.Close()
+		switch {
+		case err == nil:
+			response.Content.Size = int64(len(decoded))
+			response.Content.Compression = response.Content.Size - body.read
+			data = decoded
+		case truncated:
+			data = decoded
+		default:
+			response.Content.ContentEncoding = encoding
+			response.Content.Comment = fmt.Sprintf("failed to decode %s body: %v", encoding, err)
+		}
+	}
+	if utf8.Valid(data) {
+		response.Content.Text = string(data)
+	} else {
+		response.Content.Text = base64.StdEncoding.EncodeToString(data)
+		response.Content.Encoding = "base64"
+	}
+	return response
+}
+
+// requestHeader 返回请求头及 Host、Content-Length，用于传输层没有报告写出的头部时（如回放的请求）
+// 不包含传输层自行添加的头部，顺序也不是实际发送的顺序
+func requestHeader(req *http.Request) http.Header {
+	header := req.Header.Clone()
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	header.Set("Host", host)
+	if req.ContentLength > 0 {
+		header.Set("Content-Length", strconv.FormatInt(req.ContentLength, 10))
+	}
+	return header
+}
+
+// sortedHeaders 按名称排序返回头部，同名的值保持原顺序
+func sortedHeaders(header http.Header) []HARNameValue {
+	headers := []HARNameValue{}
+	for _, pair := range PairsFromValues(url.Values(header)) {
+		headers = append(headers, HARNameValue{Name: pair[0], Value: pair[1]})
+	}
+	return headers
+}
+
+// queryPairs 按原顺序解析查询字符串
+func queryPairs(rawQuery string) []HARNameValue {
+	pairs := []HARNameValue{}
+	for _, segment := range strings.Split(rawQuery, "&") {
+		if segment == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(segment, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if unescaped, err := url.QueryUnescape(value); err == nil {
+			value = unescaped
+		}
+		pairs = append(pairs, HARNameValue{Name: name, Value: value})
+	}
+	return pairs
+}
+
+// milliseconds 返回两个时间点之间的毫秒数
+func milliseconds(start, end time.Time) float64 {
+	if start.IsZero() || end.IsZero() {
+		return -1
+	}
+	return float64(end.Sub(start).Microseconds()) / 1000
+}
+
+// captureBody 在读取时保留开头部分的数据，并在关闭时回调
+type captureBody struct {
+	io.ReadCloser
+	limit    int64
+	captured bytes.Buffer
+	read     int64
+	onClose  func()
+	closed   bool
+}
+
+// Read 实现 io.Reader 接口
+func (b *captureBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if remaining := b.limit - int64(b.captured.Len()); remaining > 0 {
+		b.captured.Write(p[:min(int64(n), remaining)])
+	}
+	b.read += int64(n)
+	return n, err
+}
+
+// Close 关闭底层的 reader
+func (b *captureBody) Close() error {
+	err := b.ReadCloser.Close()
+	if !b.closed && b.onClose != nil {
+		b.closed = true
+		b.onClose()
+	}
+	return err
+}
+
+// truncated 判断保留的数据是否少于已读取的数据
+func (b *captureBody) truncated() bool {
+	return int64(b.captured.Len()) < b.read
+}
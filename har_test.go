@@ -0,0 +1,230 @@
+package primp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newHARServer 返回用于记录测试的服务器
+func newHARServer(t *testing.T, page []byte) *httptest.Server {
+	t.Helper()
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	gz.Write(page)
+	gz.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+			http.Redirect(w, r, "/page", http.StatusFound)
+		case "/page":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Write(gzipped.Bytes())
+		case "/form":
+			r.ParseForm()
+			w.Write([]byte("got " + r.PostForm.Get("name")))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHARRecorderRedirectAndGzip(t *testing.T) {
+	page := []byte(strings.Repeat("hello world ", 100))
+	server := newHARServer(t, page)
+	recorder := NewHARRecorder()
+	client := NewClient(WithHARRecorder(recorder))
+
+	// 手动设置 Accept-Encoding，使 net/http 不自动解压，记录的是原始的压缩数据
+	resp, err := client.Get(server.URL+"/redirect?x=1", RequestParams{Headers: map[string]string{"Accept-Encoding": "gzip"}})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if text, _ := resp.Text(); text != string(page) {
+		t.Fatalf("Text() = %q, want the decoded page", text)
+	}
+
+	entries := recorder.HAR().Log.Entries
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	first, second := entries[0], entries[1]
+	if first.Response.Status != http.StatusFound || first.Response.RedirectURL != "/page" {
+		t.Errorf("first entry = %d %q, want 302 to /page", first.Response.Status, first.Response.RedirectURL)
+	}
+	if len(first.Request.QueryString) != 1 || first.Request.QueryString[0] != (HARNameValue{Name: "x", Value: "1"}) {
+		t.Errorf("QueryString = %v, want x=1", first.Request.QueryString)
+	}
+	if len(first.Response.Cookies) != 1 || first.Response.Cookies[0].Name != "session" {
+		t.Errorf("response cookies = %v, want session", first.Response.Cookies)
+	}
+	if harHeader(first.Request.Headers, "Host") == "" {
+		t.Errorf("request headers %v missing Host", first.Request.Headers)
+	}
+
+	if len(second.Request.Cookies) != 1 || second.Request.Cookies[0].Value != "abc" {
+		t.Errorf("redirect request cookies = %v, want the jar cookie", second.Request.Cookies)
+	}
+	if harHeader(second.Request.Headers, "Cookie") != "session=abc" {
+		t.Errorf("redirect request headers %v missing the jar cookie", second.Request.Headers)
+	}
+	content := second.Response.Content
+	if content.Text != string(page) || content.Size != int64(len(page)) || content.Compression <= 0 {
+		t.Errorf("content = size %d, compression %d, %d bytes of text; want decoded page", content.Size, content.Compression, len(content.Text))
+	}
+	if content.Truncated {
+		t.Error("content marked truncated")
+	}
+	if first.Timings.Receive < 0 || first.Time < 0 {
+		t.Errorf("timings = %+v, want non-negative receive", first.Timings)
+	}
+}
+
+func TestHARRecorderTruncatedBody(t *testing.T) {
+	var page []byte
+	for i := 0; i < 5000; i++ {
+		page = strconv.AppendInt(page, int64(i*7919), 10)
+	}
+	server := newHARServer(t, page)
+	recorder := NewHARRecorder(100)
+	client := NewClient(WithHARRecorder(recorder))
+
+	if _, err := client.Get(server.URL+"/page", RequestParams{Headers: map[string]string{"Accept-Encoding": "gzip"}}); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	har := recorder.HAR()
+	content := har.Log.Entries[0].Response.Content
+	if !content.Truncated {
+		t.Fatal("content not marked truncated")
+	}
+	if content.Text == "" || !strings.HasPrefix(string(page), content.Text) || strings.HasPrefix(content.Text, "\x1f\x8b") {
+		t.Errorf("Text = %q, want a decoded prefix of the page", content.Text)
+	}
+
+	replayer, err := NewReplayer(har)
+	if err != nil {
+		t.Fatalf("NewReplayer() error = %v", err)
+	}
+	if _, err := NewClient(WithReplay(replayer)).Get(server.URL + "/page"); err == nil || !strings.Contains(err.Error(), "truncated") {
+		t.Errorf("replay error = %v, want truncated entry rejected", err)
+	}
+}
+
+func TestHARRecorderPostDataAndSave(t *testing.T) {
+	server := newHARServer(t, nil)
+	recorder := NewHARRecorder()
+	client := NewClient(WithHARRecorder(recorder))
+
+	if _, err := client.Post(server.URL+"/form", RequestParams{DataPairs: [][2]string{{"name", "a b"}, {"tag", "x"}}}); err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "out.har")
+	if err := recorder.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	har, err := LoadHAR(path)
+	if err != nil {
+		t.Fatalf("LoadHAR() error = %v", err)
+	}
+	if har.Log.Version != "1.2" || len(har.Log.Entries) != 1 {
+		t.Fatalf("loaded version %q with %d entries, want 1.2 with 1", har.Log.Version, len(har.Log.Entries))
+	}
+
+	request := har.Log.Entries[0].Request
+	if request.PostData == nil {
+		t.Fatal("PostData missing")
+	}
+	want := []HARNameValue{{Name: "name", Value: "a b"}, {Name: "tag", Value: "x"}}
+	if len(request.PostData.Params) != len(want) {
+		t.Fatalf("Params = %v, want %v", request.PostData.Params, want)
+	}
+	for i := range want {
+		if request.PostData.Params[i] != want[i] {
+			t.Errorf("Params[%d] = %v, want %v", i, request.PostData.Params[i], want[i])
+		}
+	}
+	if request.BodySize != int64(len(request.PostData.Text)) {
+		t.Errorf("BodySize = %d, want %d", request.BodySize, len(request.PostData.Text))
+	}
+	if harHeader(request.Headers, "Content-Length") == "" {
+		t.Errorf("headers %v missing the Content-Length written by the transport", request.Headers)
+	}
+	if text := har.Log.Entries[0].Response.Content.Text; text != "got a b" {
+		t.Errorf("response text = %q, want %q", text, "got a b")
+	}
+}
+
+func TestHARRecorderConcurrentExchanges(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		w.Write([]byte("got " + r.PostForm.Get("n")))
+	})
+	tests := []struct {
+		name  string
+		proto string
+		start func(*httptest.Server)
+	}{
+		{"HTTP/1.1", "HTTP/1.1", (*httptest.Server).Start},
+		{"HTTP/2", "HTTP/2.0", func(s *httptest.Server) {
+			s.EnableHTTP2 = true
+			s.StartTLS()
+		}},
+	}
+
+	// 在 -race 下运行，httptrace 回调和 finish 在不同协程中访问同一个 harExchange
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewUnstartedServer(handler)
+			tt.start(server)
+			defer server.Close()
+
+			recorder := NewHARRecorder()
+			client := NewClient(WithHARRecorder(recorder))
+			client.httpClient.Transport = server.Client().Transport
+
+			const requests = 8
+			var wg sync.WaitGroup
+			for i := 0; i < requests; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					n := strconv.Itoa(i)
+					resp, err := client.Post(server.URL+"/form", RequestParams{DataPairs: [][2]string{{"n", n}}})
+					if err != nil {
+						t.Errorf("Post() error = %v", err)
+						return
+					}
+					if text, _ := resp.Text(); text != "got "+n {
+						t.Errorf("Text() = %q, want %q", text, "got "+n)
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			entries := recorder.HAR().Log.Entries
+			if len(entries) != requests {
+				t.Fatalf("got %d entries, want %d", len(entries), requests)
+			}
+			for _, entry := range entries {
+				if entry.Request.HTTPVersion != tt.proto || entry.Response.HTTPVersion != tt.proto {
+					t.Errorf("versions = %s/%s, want %s", entry.Request.HTTPVersion, entry.Response.HTTPVersion, tt.proto)
+				}
+				if entry.ServerIPAddress != "127.0.0.1" {
+					t.Errorf("ServerIPAddress = %q, want 127.0.0.1", entry.ServerIPAddress)
+				}
+				if entry.Timings.Wait < 0 || entry.Timings.Receive < 0 || entry.Request.PostData == nil {
+					t.Errorf("entry = %+v, want non-negative timings and post data", entry)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package primp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrNotJSON 表示响应内容不是 JSON（例如返回了 HTML 验证页面）
+var ErrNotJSON = errors.New("response is not JSON")
+
+// ErrJSONPathNotFound 表示 JSON 路径在响应中不存在
+var ErrJSONPathNotFound = errors.New("json path not found")
+
+// JSONOption 是配置 JSON 解码的函数类型
+type JSONOption func(*jsonOptions)
+
+// jsonOptions 保存 JSON 解码选项
+type jsonOptions struct {
+	strict             bool
+	useNumber          bool
+	requireContentType bool
+}
+
+// JSONStrict 启用严格模式：拒绝未知字段和多余的尾随数据
+func JSONStrict() JSONOption {
+	return func(o *jsonOptions) {
+		o.strict = true
+	}
+}
+
+// JSONUseNumber 将数字解码为 json.Number 而不是 float64
+func JSONUseNumber() JSONOption {
+	return func(o *jsonOptions) {
+		o.useNumber = true
+	}
+}
+
+// JSONRequireContentType 要求 Content-Type 为 application/json 或 +json 类型
+func JSONRequireContentType() JSONOption {
+	return func(o *jsonOptions) {
+		o.requireContentType = true
+	}
+}
+
+// JSON 将响应体反序列化到提供的值中
+func (r *Response) JSON(v interface{}, opts ...JSONOption) error {
+	var options jsonOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	content, err := r.jsonContent(options.requireContentType)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(content))
+	if options.strict {
+		dec.DisallowUnknownFields()
+	}
+	if options.useNumber {
+		dec.UseNumber()
+	}
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("failed to decode JSON: %w", err)
+	}
+	if options.strict {
+		if _, err := dec.Token(); err != io.EOF {
+			return fmt.Errorf("failed to decode JSON: unexpected data after top-level value")
+		}
+	}
+	return nil
+}
+
+// JSONAs 将响应体解码为类型 T
+func JSONAs[T any](r *Response, opts ...JSONOption) (T, error) {
+	var v T
+	err := r.JSON(&v, opts...)
+	return v, err
+}
+
+// JSONPath 使用路径表达式获取嵌套的 JSON 值，无需定义结构体
+// 支持 "data.items.0.name" 和 "$.data.items[0].name" 两种写法，* 或 [*] 匹配所有元素并返回切片
+// 数字以 json.Number 返回
+func (r *Response) JSONPath(path string) (interface{}, error) {
+	if r.jsonValue == nil {
+		var value interface{}
+		if err := r.JSON(&value, JSONUseNumber()); err != nil {
+			return nil, err
+		}
+		r.jsonValue = &value
+	}
+
+	segments, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return evalJSONPath(*r.jsonValue, segments, path)
+}
+
+// JSONPathAs 获取路径对应的值并转换为类型 T
+func JSONPathAs[T any](r *Response, path string) (T, error) {
+	var result T
+	value, err := r.JSONPath(path)
+	if err != nil {
+		return result, err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return result, fmt.Errorf("failed to convert JSON value: %w", err)
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return result, fmt.Errorf("failed to convert JSON value: %w", err)
+	}
+	return result, nil
+}
+
+// jsonContent 返回去掉 BOM 的响应体，并在内容明显不是 JSON 时返回 ErrNotJSON
+// 许多接口以 text/html 返回 JSON，因此只根据内容判断 HTML，仅在 requireContentType 时检查媒体类型
+func (r *Response) jsonContent(requireContentType bool) ([]byte, error) {
+	content, err := r.Content()
+	if err != nil {
+		return nil, err
+	}
+	content = bytes.TrimPrefix(content, []byte("\ufeff"))
+
+	contentType := r.httpResp.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	trimmed := bytes.TrimLeft(content, " \t\r\n")
+
+	isHTML := bytes.HasPrefix(trimmed, []byte("<"))
+	isJSON := mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+	if isHTML || (requireContentType && !isJSON) {
+		snippet := trimmed
+		if len(snippet) > 80 {
+			snippet = snippet[:80]
+		}
+		return nil, fmt.Errorf("%w: status %d, content type %q, body starts with %q", ErrNotJSON, r.StatusCode, contentType, snippet)
+	}
+	return content, nil
+}
+
+// jsonPathSegment 表示 JSON 路径中的一段
+type jsonPathSegment struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// parseJSONPath 解析路径表达式
+func parseJSONPath(path string) ([]jsonPathSegment, error) {
+	p := strings.TrimSpace(path)
+	p = strings.TrimPrefix(p, "$")
+
+	var segments []jsonPathSegment
+	for len(p) > 0 {
+		switch p[0] {
+		case '.':
+			p = p[1:]
+		case '[':
+			end := strings.IndexByte(p, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid json path %q: missing ]", path)
+			}
+			inner := strings.TrimSpace(p[1:end])
+			p = p[end+1:]
+			switch {
+			case inner == "*":
+				segments = append(segments, jsonPathSegment{wildcard: true})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				segments = append(segments, jsonPathSegment{key: inner[1 : len(inner)-1]})
+			default:
+				index, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid json path %q: bad index %q", path, inner)
+				}
+				segments = append(segments, jsonPathSegment{index: index, isIndex: true})
+			}
+		default:
+			end := strings.IndexAny(p, ".[")
+			if end < 0 {
+				end = len(p)
+			}
+			name := p[:end]
+			p = p[end:]
+			if name == "*" {
+				segments = append(segments, jsonPathSegment{wildcard: true})
+			} else if index, err := strconv.Atoi(name); err == nil {
+				// 点号写法中的数字既可以是数组下标也可以是对象键
+				segments = append(segments, jsonPathSegment{key: name, index: index, isIndex: true})
+			} else {
+				segments = append(segments, jsonPathSegment{key: name})
+			}
+		}
+	}
+	return segments, nil
+}
+
+// evalJSONPath 在解码后的 JSON 值上执行路径
+func evalJSONPath(root interface{}, segments []jsonPathSegment, path string) (interface{}, error) {
+	current := []interface{}{root}
+	multiple := false
+
+	for _, seg := range segments {
+		var next []interface{}
+		for _, value := range current {
+			switch v := value.(type) {
+			case map[string]interface{}:
+				if seg.wildcard {
+					next = append(next, sortedMapValues(v)...)
+				} else if child, ok := v[seg.key]; ok && (seg.key != "" || !seg.isIndex) {
+					next = append(next, child)
+				}
+			case []interface{}:
+				if seg.wildcard {
+					next = append(next, v...)
+				} else if seg.isIndex {
+					index := seg.index
+					if index < 0 {
+						index += len(v)
+					}
+					if index >= 0 && index < len(v) {
+						next = append(next, v[index])
+					}
+				}
+			}
+		}
+		if seg.wildcard {
+			multiple = true
+		}
+		current = next
+	}
+
+	if multiple {
+		if current == nil {
+			current = []interface{}{}
+		}
+		return current, nil
+	}
+	if len(current) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrJSONPathNotFound, path)
+	}
+	return current[0], nil
+}
+
+// sortedMapValues 按键排序返回对象的所有值，保证通配符结果稳定
+func sortedMapValues(m map[string]interface{}) []interface{} {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	values := make([]interface{}, 0, len(keys))
+	for _, k := range keys {
+		values = append(values, m[k])
+	}
+	return values
+}
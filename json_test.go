@@ -0,0 +1,230 @@
+package primp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJSONContentDetection(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		opts        []JSONOption
+		wantErr     error
+	}{
+		{name: "json served as html", contentType: "text/html", body: `{"a":1}`},
+		{name: "utf-8 bom", contentType: "application/json", body: "\ufeff{\"a\":1}"},
+		{name: "html page", contentType: "text/html", body: "\n<!DOCTYPE html><html></html>", wantErr: ErrNotJSON},
+		{name: "html behind json content type", contentType: "application/json", body: "<html></html>", wantErr: ErrNotJSON},
+		{name: "require content type", contentType: "text/plain", body: `{"a":1}`, opts: []JSONOption{JSONRequireContentType()}, wantErr: ErrNotJSON},
+		{name: "require content type suffix", contentType: "application/problem+json", body: `{"a":1}`, opts: []JSONOption{JSONRequireContentType()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			resp, err := NewClient().Get(server.URL)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			defer resp.Close()
+
+			var v struct{ A int }
+			err = resp.JSON(&v, tt.opts...)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("JSON() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("JSON() error = %v", err)
+			}
+			if v.A != 1 {
+				t.Errorf("A = %d, want 1", v.A)
+			}
+		})
+	}
+}
+
+// jsonResponse 返回内容为 body 的 JSON 响应
+func jsonResponse(t *testing.T, body string) *Response {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	resp, err := NewClient().Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	return resp
+}
+
+const jsonPathDocument = `{
+	"data": {
+		"items": [
+			{"name": "a", "id": 9007199254740993},
+			{"name": "b", "id": 2, "tags": ["x", "y"]}
+		],
+		"0": "zero key",
+		"count": 2,
+		"odd.key": true
+	}
+}`
+
+func TestJSONPath(t *testing.T) {
+	resp := jsonResponse(t, jsonPathDocument)
+	tests := []struct {
+		path    string
+		want    interface{}
+		wantErr error
+	}{
+		{path: "data.items.0.name", want: "a"},
+		{path: "$.data.items[1].name", want: "b"},
+		{path: "data.items[-1].tags[0]", want: "x"},
+		{path: "data.items.*.name", want: []interface{}{"a", "b"}},
+		{path: "data.items[*].tags", want: []interface{}{[]interface{}{"x", "y"}}},
+		{path: "data.0", want: "zero key"},
+		{path: `data["odd.key"]`, want: true},
+		{path: "data.count", want: json.Number("2")},
+		{path: "data.items.0.id", want: json.Number("9007199254740993")},
+		{path: "data.missing.*", want: []interface{}{}},
+		{path: "data.missing", wantErr: ErrJSONPathNotFound},
+		{path: "data.items[5]", wantErr: ErrJSONPathNotFound},
+		{path: "data[0]", wantErr: ErrJSONPathNotFound},
+		{path: "data.items.name", wantErr: ErrJSONPathNotFound},
+		{path: "data.count.value", wantErr: ErrJSONPathNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := resp.JSONPath(tt.path)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("JSONPath() = %v, %v; want error %v", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("JSONPath() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("JSONPath() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONPathSyntaxErrors(t *testing.T) {
+	resp := jsonResponse(t, jsonPathDocument)
+	for _, path := range []string{"data.items[0", "data.items[x]"} {
+		if _, err := resp.JSONPath(path); err == nil || !strings.Contains(err.Error(), "invalid json path") {
+			t.Errorf("JSONPath(%q) error = %v, want invalid json path", path, err)
+		}
+	}
+}
+
+func TestJSONPathAs(t *testing.T) {
+	resp := jsonResponse(t, jsonPathDocument)
+
+	id, err := JSONPathAs[int64](resp, "data.items.0.id")
+	if err != nil || id != 9007199254740993 {
+		t.Errorf("JSONPathAs[int64]() = %d, %v; want 9007199254740993", id, err)
+	}
+	names, err := JSONPathAs[[]string](resp, "data.items.*.name")
+	if err != nil || !reflect.DeepEqual(names, []string{"a", "b"}) {
+		t.Errorf("JSONPathAs[[]string]() = %v, %v", names, err)
+	}
+	type item struct {
+		Name string
+		Tags []string
+	}
+	got, err := JSONPathAs[item](resp, "data.items[1]")
+	if err != nil || got.Name != "b" || len(got.Tags) != 2 {
+		t.Errorf("JSONPathAs[item]() = %+v, %v", got, err)
+	}
+
+	if _, err := JSONPathAs[int](resp, "data.items.0.name"); err == nil || !strings.Contains(err.Error(), "failed to convert JSON value") {
+		t.Errorf("JSONPathAs[int](string) error = %v, want a conversion error", err)
+	}
+	if _, err := JSONPathAs[string](resp, "data.nope"); !errors.Is(err, ErrJSONPathNotFound) {
+		t.Errorf("JSONPathAs() error = %v, want %v", err, ErrJSONPathNotFound)
+	}
+}
+
+func TestJSONAsOptions(t *testing.T) {
+	type payload struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+	tests := []struct {
+		name    string
+		body    string
+		opts    []JSONOption
+		want    payload
+		wantErr string
+	}{
+		{name: "unknown fields allowed", body: `{"id":1,"name":"a","extra":true}`, want: payload{1, "a"}},
+		{name: "trailing data allowed", body: `{"id":1} {"id":2}`, want: payload{ID: 1}},
+		{name: "strict rejects unknown fields", body: `{"id":1,"extra":true}`, opts: []JSONOption{JSONStrict()}, wantErr: "unknown field"},
+		{name: "strict rejects trailing data", body: `{"id":1} {"id":2}`, opts: []JSONOption{JSONStrict()}, wantErr: "unexpected data after top-level value"},
+		{name: "strict allows trailing whitespace", body: "{\"id\":1}\n\t", opts: []JSONOption{JSONStrict()}, want: payload{ID: 1}},
+		{name: "type mismatch", body: `{"id":"one"}`, wantErr: "cannot unmarshal string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JSONAs[payload](jsonResponse(t, tt.body), tt.opts...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("JSONAs() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("JSONAs() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("JSONAs() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONUseNumber(t *testing.T) {
+	const body = `{"id":9007199254740993}`
+
+	loose, err := JSONAs[map[string]interface{}](jsonResponse(t, body))
+	if err != nil {
+		t.Fatalf("JSONAs() error = %v", err)
+	}
+	if f, ok := loose["id"].(float64); !ok || int64(f) == 9007199254740993 {
+		t.Errorf("id = %#v, want a float64 that loses precision", loose["id"])
+	}
+
+	exact, err := JSONAs[map[string]interface{}](jsonResponse(t, body), JSONUseNumber())
+	if err != nil {
+		t.Fatalf("JSONAs() error = %v", err)
+	}
+	number, ok := exact["id"].(json.Number)
+	if !ok {
+		t.Fatalf("id = %#v, want json.Number", exact["id"])
+	}
+	if id, err := number.Int64(); err != nil || id != 9007199254740993 {
+		t.Errorf("Int64() = %d, %v; want 9007199254740993", id, err)
+	}
+}
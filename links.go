@@ -0,0 +1,116 @@
+package primp
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Link 表示页面中引用的一个 URL
+type Link struct {
+	URL  string
+	Text string
+	Tag  string
+	Rel  string
+}
+
+// linkAttributes 是各元素中包含 URL 的属性
+var linkAttributes = map[atom.Atom]string{
+	atom.A:      "href",
+	atom.Area:   "href",
+	atom.Link:   "href",
+	atom.Script: "src",
+	atom.Img:    "src",
+	atom.Iframe: "src",
+	atom.Source: "src",
+	atom.Video:  "src",
+	atom.Audio:  "src",
+	atom.Embed:  "src",
+}
+
+// Links 返回页面中所有链接、脚本、样式表和图片的绝对 URL，按文档顺序排列
+// 相对地址基于最终响应 URL 和 <base href> 解析
+func (r *Response) Links() ([]Link, error) {
+	doc, err := r.document()
+	if err != nil {
+		return nil, err
+	}
+
+	return extractLinks(doc, r.URL), nil
+}
+
+// extractLinks 从文档树中提取链接
+func extractLinks(doc *html.Node, baseURL string) []Link {
+	base := documentBaseURL(doc, baseURL)
+	var links []Link
+
+	add := func(n *html.Node, ref string) {
+		ref = strings.TrimSpace(ref)
+		lower := strings.ToLower(ref)
+		if ref == "" || strings.HasPrefix(lower, "javascript:") || strings.HasPrefix(lower, "data:") {
+			return
+		}
+		rel, _ := getAttr(n, "rel")
+		text := ""
+		if n.DataAtom == atom.A || n.DataAtom == atom.Area {
+			text = innerText(n)
+			if text == "" {
+				text, _ = getAttr(n, "title")
+			}
+		} else if n.DataAtom == atom.Img {
+			text, _ = getAttr(n, "alt")
+		}
+		links = append(links, Link{
+			URL:  resolveURL(base, ref),
+			Text: text,
+			Tag:  n.Data,
+			Rel:  rel,
+		})
+	}
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if attr, ok := linkAttributes[n.DataAtom]; ok {
+				if ref, ok := getAttr(n, attr); ok {
+					add(n, ref)
+				}
+			}
+			if n.DataAtom == atom.Img || n.DataAtom == atom.Source {
+				if srcset, ok := getAttr(n, "srcset"); ok {
+					for _, ref := range parseSrcset(srcset) {
+						add(n, ref)
+					}
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+	return links
+}
+
+// parseSrcset 返回 srcset 属性中的所有 URL
+func parseSrcset(srcset string) []string {
+	var refs []string
+	for _, candidate := range strings.Split(srcset, ",") {
+		if fields := strings.Fields(candidate); len(fields) > 0 {
+			refs = append(refs, fields[0])
+		}
+	}
+	return refs
+}
+
+// pageURL 返回去掉片段的页面地址，用作 Referer
+func pageURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	u.Fragment = ""
+	return u.String()
+}
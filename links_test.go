@@ -0,0 +1,74 @@
+package primp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLinks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head>
+<base href="/assets/">
+<link rel="stylesheet" href="site.css">
+</head><body>
+<a href="guide.html" rel="nofollow noopener">Guide</a>
+<a href="https://other.example/" title="Other site"><img src="logo.png" alt="Logo" srcset="logo-2x.png 2x, /abs.png 3x"></a>
+<a href="javascript:void(0)">Script</a>
+<a href="#top">Top</a>
+</body></html>`))
+	}))
+	defer server.Close()
+
+	resp, err := NewClient().Get(server.URL + "/docs/page.html")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	links, err := resp.Links()
+	if err != nil {
+		t.Fatalf("Links() error = %v", err)
+	}
+
+	want := []Link{
+		{URL: server.URL + "/assets/site.css", Tag: "link", Rel: "stylesheet"},
+		{URL: server.URL + "/assets/guide.html", Text: "Guide", Tag: "a", Rel: "nofollow noopener"},
+		{URL: "https://other.example/", Text: "Other site", Tag: "a"},
+		{URL: server.URL + "/assets/logo.png", Text: "Logo", Tag: "img"},
+		{URL: server.URL + "/assets/logo-2x.png", Text: "Logo", Tag: "img"},
+		{URL: server.URL + "/abs.png", Text: "Logo", Tag: "img"},
+		{URL: server.URL + "/assets/#top", Text: "Top", Tag: "a"},
+	}
+	if len(links) != len(want) {
+		t.Fatalf("Links() = %+v, want %d links", links, len(want))
+	}
+	for i := range want {
+		if links[i] != want[i] {
+			t.Errorf("Links()[%d] = %+v, want %+v", i, links[i], want[i])
+		}
+	}
+}
+
+func TestLinksResolveAgainstFinalURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/start" {
+			http.Redirect(w, r, "/docs/page.html", http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<a href="next.html">Next</a>`))
+	}))
+	defer server.Close()
+
+	resp, err := NewClient().Get(server.URL + "/start")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	links, err := resp.Links()
+	if err != nil {
+		t.Fatalf("Links() error = %v", err)
+	}
+	if len(links) != 1 || links[0].URL != server.URL+"/docs/next.html" {
+		t.Errorf("Links() = %+v, want next.html resolved against the redirect target", links)
+	}
+}
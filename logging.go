@@ -0,0 +1,217 @@
+package primp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLogBodySnippet 是调试日志中记录的请求体和响应体最大字节数
+const maxLogBodySnippet = 512
+
+// sensitiveHeaders 是日志中需要隐藏值的头部
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+	"X-Auth-Token":        true,
+}
+
+var (
+	// sensitiveFormField 匹配表单请求体中需要隐藏值的字段
+	sensitiveFormField = regexp.MustCompile(`(?i)((?:^|&)[^=&]*(?:pass|secret|token|api_?key|auth)[^=&]*=)[^&]*`)
+	// sensitiveJSONField 匹配 JSON 请求体中需要隐藏值的字符串字段
+	sensitiveJSONField = regexp.MustCompile(`(?i)("[^"]*(?:pass|secret|token|api_?key|auth)[^"]*"\s*:\s*)"(?:[^"\\]|\\.)*"?`)
+)
+
+// logRoundTrip 返回记录请求日志的 RoundTripFunc，位于中间件链最内层，记录的是逻辑请求
+// 即经过中间件后、交给 http.Client 之前的请求，不包含 Cookie Jar 添加的 Cookie 和传输层自行添加的头部，
+// 重定向的中间请求也不会单独记录，只通过 final_url 和 redirects 体现；需要逐跳记录实际发送的内容时使用 WithHARRecorder
+func (c *Client) logRoundTrip(next RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		ctx := req.Context()
+		debug := c.logger.Enabled(ctx, slog.LevelDebug)
+		attrs := []slog.Attr{
+			slog.String("method", req.Method),
+			slog.String("url", req.URL.Redacted()),
+		}
+		if proxy := c.proxyFor(req); proxy != "" {
+			attrs = append(attrs, slog.String("proxy", proxy))
+		}
+		if debug {
+			attrs = append(attrs, slog.Any("request_headers", redactHeaders(req.Header)))
+			if snippet, ok := requestBodySnippet(req); ok {
+				attrs = append(attrs, slog.String("request_body", redactBody(snippet, req.Header.Get("Content-Type"))))
+			}
+		}
+
+		start := time.Now()
+		resp, err := next(req)
+		attrs = append(attrs, slog.Duration("duration", time.Since(start)))
+		if err != nil {
+			attrs = append(attrs, slog.Any("error", err))
+			c.logger.LogAttrs(ctx, slog.LevelError, "request failed", attrs...)
+			return resp, err
+		}
+
+		attrs = append(attrs, slog.Int("status", resp.StatusCode), slog.String("proto", resp.Proto))
+		if resp.Request != nil && resp.Request.URL.String() != req.URL.String() {
+			attrs = append(attrs, slog.String("final_url", resp.Request.URL.Redacted()))
+		}
+		if redirects := redirectCount(resp); redirects > 0 {
+			attrs = append(attrs, slog.Int("redirects", redirects))
+		}
+		if debug {
+			attrs = append(attrs, slog.Any("response_headers", redactHeaders(resp.Header)))
+			// 响应体可能是流，因此在关闭时才记录读到的片段
+			resp.Body = &loggedBody{ReadCloser: resp.Body, logger: c.logger, ctx: ctx, method: req.Method, url: req.URL.Redacted(),
+				encoding: resp.Header.Get("Content-Encoding")}
+		}
+		c.logger.LogAttrs(ctx, slog.LevelInfo, "request", attrs...)
+		return resp, nil
+	}
+}
+
+// proxyFor 返回请求使用的代理地址，密码会被隐藏
+func (c *Client) proxyFor(req *http.Request) string {
+	transport, ok := c.httpClient.Transport.(*http.Transport)
+	if !ok || transport.Proxy == nil {
+		return ""
+	}
+	proxyURL, err := transport.Proxy(req)
+	if err != nil || proxyURL == nil {
+		return ""
+	}
+	return proxyURL.Redacted()
+}
+
+// redirectCount 返回得到响应前经过的重定向次数
+func redirectCount(resp *http.Response) int {
+	count := 0
+	for req := resp.Request; req != nil && req.Response != nil; req = req.Response.Request {
+		count++
+	}
+	return count
+}
+
+// redactHeaders 返回隐藏了敏感值的头部副本
+func redactHeaders(header http.Header) map[string]string {
+	redacted := make(map[string]string, len(header))
+	for name, values := range header {
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+			redacted[name] = "[REDACTED]"
+			continue
+		}
+		value := values[0]
+		for _, v := range values[1:] {
+			value += ", " + v
+		}
+		redacted[name] = value
+	}
+	return redacted
+}
+
+// requestBodySnippet 返回请求体的开头部分，只处理可以重复读取的请求体
+func requestBodySnippet(req *http.Request) (string, bool) {
+	if req.GetBody == nil || req.Body == nil || req.Body == http.NoBody {
+		return "", false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return "", false
+	}
+	defer body.Close()
+	data, err := io.ReadAll(io.LimitReader(body, maxLogBodySnippet))
+	if err != nil {
+		return "", false
+	}
+	return bodySnippet(data, req.ContentLength), true
+}
+
+// redactBody 隐藏表单和 JSON 请求体中密码、令牌等字段的值
+func redactBody(snippet, contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		return sensitiveFormField.ReplaceAllString(snippet, "${1}[REDACTED]")
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return sensitiveJSONField.ReplaceAllString(snippet, `${1}"[REDACTED]"`)
+	}
+	return snippet
+}
+
+// bodySnippet 格式化日志中的请求体或响应体片段，二进制内容只记录长度
+func bodySnippet(data []byte, total int64) string {
+	if !utf8.Valid(data) && !utf8.Valid(trimPartialRune(data)) {
+		return fmt.Sprintf("[binary %d bytes]", len(data))
+	}
+	if total > int64(len(data)) {
+		return string(data) + fmt.Sprintf("...[%d bytes total]", total)
+	}
+	return string(data)
+}
+
+// loggedBody 记录读到的响应体开头部分，并在关闭时写入日志
+// 读到的是未解压的字节，记录前按 Content-Encoding 解码片段
+type loggedBody struct {
+	io.ReadCloser
+	logger   *slog.Logger
+	ctx      context.Context
+	method   string
+	url      string
+	encoding string
+	snippet  bytes.Buffer
+	read     int64
+	logged   bool
+}
+
+// Read 实现 io.Reader 接口
+func (b *loggedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if remaining := maxLogBodySnippet - b.snippet.Len(); remaining > 0 {
+		b.snippet.Write(p[:min(n, remaining)])
+	}
+	b.read += int64(n)
+	return n, err
+}
+
+// Close 关闭响应体并记录片段
+func (b *loggedBody) Close() error {
+	if !b.logged {
+		b.logged = true
+		b.logger.LogAttrs(b.ctx, slog.LevelDebug, "response body",
+			slog.String("method", b.method),
+			slog.String("url", b.url),
+			slog.Int64("bytes_read", b.read),
+			slog.String("body", b.body()),
+		)
+	}
+	return b.ReadCloser.Close()
+}
+
+// body 返回解码后的响应体片段，压缩数据被截断时只记录能解出的部分
+func (b *loggedBody) body() string {
+	if b.encoding == "" {
+		return bodySnippet(b.snippet.Bytes(), b.read)
+	}
+	decoder := newDecodedBody(bytes.NewReader(b.snippet.Bytes()), b.encoding)
+	defer decoder.Close()
+	decoded, err := io.ReadAll(io.LimitReader(decoder, maxLogBodySnippet))
+	if len(decoded) == 0 && err != nil {
+		return fmt.Sprintf("[%s %d bytes]", b.encoding, b.read)
+	}
+	if err != nil || int64(b.snippet.Len()) < b.read || len(decoded) == maxLogBodySnippet {
+		return bodySnippet(decoded, -1) + "..."
+	}
+	return bodySnippet(decoded, -1)
+}
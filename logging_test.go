@@ -0,0 +1,140 @@
+package primp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// logRecords 解析 JSON handler 输出的日志记录
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// findRecord 返回第一条消息为 msg 的日志记录
+func findRecord(t *testing.T, records []map[string]any, msg string) map[string]any {
+	t.Helper()
+	for _, record := range records {
+		if record["msg"] == msg {
+			return record
+		}
+	}
+	t.Fatalf("no %q log record in %v", msg, records)
+	return nil
+}
+
+func TestLoggerRedactsSensitiveValues(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "server-secret"})
+		w.Header().Set("X-Request-Id", "abc")
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	client := NewClient(WithLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+	resp, err := client.Post(server.URL, RequestParams{
+		Headers: map[string]string{
+			"Authorization": "Bearer header-secret",
+			"Cookie":        "sid=cookie-secret",
+			"X-Trace":       "visible",
+		},
+		DataPairs: [][2]string{{"user", "alice"}, {"password", "form-secret"}, {"access_token", "token-secret"}},
+	})
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	if _, err := resp.Content(); err != nil {
+		t.Fatalf("Content() error = %v", err)
+	}
+
+	output := buf.String()
+	for _, secret := range []string{"header-secret", "cookie-secret", "server-secret", "form-secret", "token-secret"} {
+		if strings.Contains(output, secret) {
+			t.Errorf("log output contains %q:\n%s", secret, output)
+		}
+	}
+
+	record := findRecord(t, logRecords(t, &buf), "request")
+	requestHeaders, _ := record["request_headers"].(map[string]any)
+	for name, want := range map[string]string{"Authorization": "[REDACTED]", "Cookie": "[REDACTED]", "X-Trace": "visible"} {
+		if requestHeaders[name] != want {
+			t.Errorf("request_headers[%s] = %v, want %q", name, requestHeaders[name], want)
+		}
+	}
+	responseHeaders, _ := record["response_headers"].(map[string]any)
+	if responseHeaders["Set-Cookie"] != "[REDACTED]" || responseHeaders["X-Request-Id"] != "abc" {
+		t.Errorf("response_headers = %v, want Set-Cookie redacted and X-Request-Id kept", responseHeaders)
+	}
+	if want := "user=alice&password=[REDACTED]&access_token=[REDACTED]"; record["request_body"] != want {
+		t.Errorf("request_body = %v, want %q", record["request_body"], want)
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{"form", "application/x-www-form-urlencoded", "user=a&Password=x&api_key=y", "user=a&Password=[REDACTED]&api_key=[REDACTED]"},
+		{"form first field", "application/x-www-form-urlencoded; charset=utf-8", "client_secret=s&x=1", "client_secret=[REDACTED]&x=1"},
+		{"json", "application/json", `{"user":"a","password":"p\"q","nested":{"refreshToken": "t"}}`, `{"user":"a","password":"[REDACTED]","nested":{"refreshToken": "[REDACTED]"}}`},
+		{"truncated json", "application/vnd.api+json", `{"password":"abc`, `{"password":"[REDACTED]"`},
+		{"plain text", "text/plain", "password=visible", "password=visible"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactBody(tt.body, tt.contentType); got != tt.want {
+				t.Errorf("redactBody() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoggerDecodesCompressedResponseBody(t *testing.T) {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(`{"message":"hello from gzip"}`))
+	gz.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(compressed.Bytes())
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	client := NewClient(WithLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+	resp, err := client.Get(server.URL, RequestParams{Headers: map[string]string{"Accept-Encoding": "gzip"}})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if _, err := resp.Content(); err != nil {
+		t.Fatalf("Content() error = %v", err)
+	}
+
+	record := findRecord(t, logRecords(t, &buf), "response body")
+	if want := `{"message":"hello from gzip"}`; record["body"] != want {
+		t.Errorf("body = %v, want %q", record["body"], want)
+	}
+	if got, ok := record["bytes_read"].(float64); !ok || int(got) != compressed.Len() {
+		t.Errorf("bytes_read = %v, want %d", record["bytes_read"], compressed.Len())
+	}
+}
@@ -0,0 +1,495 @@
+package primp
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// skippedElements 是转换为文本时忽略的元素
+var skippedElements = map[atom.Atom]bool{
+	atom.Head:     true,
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Svg:      true,
+	atom.Canvas:   true,
+	atom.Iframe:   true,
+	atom.Object:   true,
+	atom.Nav:      true,
+	atom.Button:   true,
+	atom.Select:   true,
+	atom.Input:    true,
+	atom.Textarea: true,
+}
+
+// blockElements 是按块处理的元素
+var blockElements = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Blockquote: true,
+	atom.Body: true, atom.Dd: true, atom.Details: true, atom.Dialog: true,
+	atom.Div: true, atom.Dl: true, atom.Dt: true, atom.Fieldset: true,
+	atom.Figcaption: true, atom.Figure: true, atom.Footer: true, atom.Form: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Header: true, atom.Hgroup: true, atom.Hr: true, atom.Html: true,
+	atom.Li: true, atom.Main: true, atom.Ol: true, atom.P: true, atom.Pre: true,
+	atom.Section: true, atom.Summary: true, atom.Table: true, atom.Ul: true,
+	atom.Caption: true, atom.Tbody: true, atom.Thead: true, atom.Tfoot: true,
+	atom.Tr: true, atom.Td: true, atom.Th: true,
+}
+
+// markdownConverter 将 HTML 文档树转换为 Markdown
+type markdownConverter struct {
+	base *url.URL
+}
+
+// htmlToMarkdown 将 HTML 转换为 Markdown，相对链接基于 baseURL 和 <base href> 解析
+func htmlToMarkdown(doc *html.Node, baseURL string) string {
+	c := &markdownConverter{base: documentBaseURL(doc, baseURL)}
+	blocks := c.blocks(doc)
+	if len(blocks) == 0 {
+		return ""
+	}
+	return strings.Join(blocks, "\n\n") + "\n"
+}
+
+// documentBaseURL 返回文档的基准 URL，优先使用 <base href>
+func documentBaseURL(doc *html.Node, baseURL string) *url.URL {
+	base, _ := url.Parse(baseURL)
+	if n := findElement(doc, atom.Base); n != nil {
+		if href, ok := getAttr(n, "href"); ok {
+			if base == nil {
+				base, _ = url.Parse(href)
+			} else if ref, err := base.Parse(strings.TrimSpace(href)); err == nil {
+				base = ref
+			}
+		}
+	}
+	return base
+}
+
+// resolveURL 将 ref 解析为绝对 URL，无法解析时原样返回
+func resolveURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if base == nil || ref == "" {
+		return ref
+	}
+	u, err := base.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return u.String()
+}
+
+// findElement 返回第一个指定类型的元素
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// getAttr 返回元素的属性值
+func getAttr(n *html.Node, key string) (string, bool) {
+	for _, attr := range n.Attr {
+		if attr.Namespace == "" && attr.Key == key {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+// isBlock 判断节点是否为块级元素
+func isBlock(n *html.Node) bool {
+	return n.Type == html.ElementNode && blockElements[n.DataAtom]
+}
+
+// blocks 将节点的子节点转换为 Markdown 块，连续的行内内容合并为段落
+func (c *markdownConverter) blocks(n *html.Node) []string {
+	var blocks []string
+	var inline strings.Builder
+
+	flush := func() {
+		if text := cleanInline(inline.String()); text != "" {
+			blocks = append(blocks, escapeLineStarts(text))
+		}
+		inline.Reset()
+	}
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && (skippedElements[child.DataAtom] || isHiddenElement(child)) {
+			continue
+		}
+		if isBlock(child) {
+			flush()
+			blocks = append(blocks, c.block(child)...)
+			continue
+		}
+		inline.WriteString(c.inline(child))
+	}
+	flush()
+
+	return blocks
+}
+
+// block 转换单个块级元素
+func (c *markdownConverter) block(n *html.Node) []string {
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		text := strings.TrimSpace(collapseWhitespace(c.inlineChildren(n)))
+		if text == "" {
+			return nil
+		}
+		level := int(n.Data[1] - '0')
+		return []string{strings.Repeat("#", level) + " " + text}
+	case atom.Hr:
+		return []string{"---"}
+	case atom.Pre:
+		return []string{c.codeBlock(n)}
+	case atom.Blockquote:
+		inner := c.blocks(n)
+		if len(inner) == 0 {
+			return nil
+		}
+		return []string{prefixLines(strings.Join(inner, "\n\n"), "> ", "> ")}
+	case atom.Ul, atom.Ol:
+		if list := c.list(n); list != "" {
+			return []string{list}
+		}
+		return nil
+	case atom.Table:
+		if table := c.table(n); table != "" {
+			return []string{table}
+		}
+		return nil
+	default:
+		return c.blocks(n)
+	}
+}
+
+// codeBlock 将 <pre> 转换为围栏代码块
+func (c *markdownConverter) codeBlock(n *html.Node) string {
+	lang := ""
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.DataAtom == atom.Code {
+			class, _ := getAttr(child, "class")
+			for _, name := range strings.Fields(class) {
+				if strings.HasPrefix(name, "language-") {
+					lang = strings.TrimPrefix(name, "language-")
+				} else if strings.HasPrefix(name, "lang-") {
+					lang = strings.TrimPrefix(name, "lang-")
+				}
+			}
+		}
+	}
+
+	code := strings.TrimSuffix(textContent(n), "\n")
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	return fence + lang + "\n" + code + "\n" + fence
+}
+
+// list 转换有序或无序列表
+func (c *markdownConverter) list(n *html.Node) string {
+	ordered := n.DataAtom == atom.Ol
+	index := 1
+	if start, ok := getAttr(n, "start"); ok {
+		if n, err := strconv.Atoi(strings.TrimSpace(start)); err == nil {
+			index = n
+		}
+	}
+
+	var items []string
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || child.DataAtom != atom.Li {
+			continue
+		}
+
+		marker := "- "
+		if ordered {
+			marker = fmt.Sprintf("%d. ", index)
+			index++
+		}
+		content := strings.Join(c.blocks(child), "\n")
+		items = append(items, prefixLines(content, marker, strings.Repeat(" ", len(marker))))
+	}
+	return strings.Join(items, "\n")
+}
+
+// table 将表格转换为 GFM 表格，第一行作为表头
+func (c *markdownConverter) table(n *html.Node) string {
+	var rows [][]string
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			switch child.DataAtom {
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				walk(child)
+			case atom.Tr:
+				var row []string
+				for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+						text := collapseWhitespace(c.inlineChildren(cell))
+						row = append(row, strings.ReplaceAll(strings.TrimSpace(text), "|", `\|`))
+					}
+				}
+				rows = append(rows, row)
+			}
+		}
+	}
+	walk(n)
+
+	columns := 0
+	for _, row := range rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+	if columns == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	writeRow := func(row []string) {
+		sb.WriteString("|")
+		for i := 0; i < columns; i++ {
+			cell := ""
+			if i < len(row) {
+				cell = row[i]
+			}
+			sb.WriteString(" " + cell + " |")
+		}
+		sb.WriteString("\n")
+	}
+
+	writeRow(rows[0])
+	sb.WriteString("|" + strings.Repeat(" --- |", columns) + "\n")
+	for _, row := range rows[1:] {
+		writeRow(row)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// inlineChildren 转换元素的所有子节点为行内 Markdown
+func (c *markdownConverter) inlineChildren(n *html.Node) string {
+	var sb strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		sb.WriteString(c.inline(child))
+	}
+	return sb.String()
+}
+
+// inline 转换行内节点
+func (c *markdownConverter) inline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return escapeMarkdown(collapseWhitespace(n.Data))
+	case html.ElementNode:
+	default:
+		return ""
+	}
+	if skippedElements[n.DataAtom] || isHiddenElement(n) {
+		return ""
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		return "  \n"
+	case atom.A:
+		text := strings.TrimSpace(c.inlineChildren(n))
+		href, _ := getAttr(n, "href")
+		if href == "" || strings.HasPrefix(strings.ToLower(strings.TrimSpace(href)), "javascript:") {
+			return text
+		}
+		href = resolveURL(c.base, href)
+		if text == "" {
+			text = escapeMarkdown(href)
+		}
+		return "[" + text + "](" + markdownLinkDestination(href) + ")"
+	case atom.Img:
+		src, _ := getAttr(n, "src")
+		if src == "" {
+			return ""
+		}
+		alt, _ := getAttr(n, "alt")
+		return "![" + escapeMarkdown(collapseWhitespace(alt)) + "](" + markdownLinkDestination(resolveURL(c.base, src)) + ")"
+	case atom.Strong, atom.B:
+		return wrapInline(c.inlineChildren(n), "**")
+	case atom.Em, atom.I:
+		return wrapInline(c.inlineChildren(n), "*")
+	case atom.Del, atom.S, atom.Strike:
+		return wrapInline(c.inlineChildren(n), "~~")
+	case atom.Code, atom.Kbd, atom.Samp:
+		return inlineCode(collapseWhitespace(textContent(n)))
+	default:
+		// 出现在行内上下文中的块级元素按空格分隔
+		text := c.inlineChildren(n)
+		if isBlock(n) {
+			return " " + text + " "
+		}
+		return text
+	}
+}
+
+// wrapInline 用标记包裹行内文本，并把首尾空白移到标记外
+func wrapInline(text, marker string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	leading := text[:strings.Index(text, trimmed)]
+	trailing := text[len(leading)+len(trimmed):]
+	return leading + marker + trimmed + marker + trailing
+}
+
+// inlineCode 生成行内代码，必要时使用更长的反引号
+func inlineCode(code string) string {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return ""
+	}
+	fence := "`"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+		code = " " + code + " "
+	}
+	return fence + code + fence
+}
+
+// markdownLinkDestination 转义链接目标中的空格和括号
+func markdownLinkDestination(href string) string {
+	return strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29").Replace(href)
+}
+
+// markdownEscaper 转义 Markdown 特殊字符
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"*", `\*`,
+	"_", `\_`,
+	"`", "\\`",
+	"[", `\[`,
+	"]", `\]`,
+	"<", `\<`,
+)
+
+// escapeMarkdown 转义文本中的 Markdown 特殊字符
+func escapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+// escapeLineStarts 转义段落中每行开头会被解析为标题、列表、引用或分隔线的标记
+func escapeLineStarts(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = escapeLineStart(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// escapeLineStart 转义一行开头的块级标记，* 和 _ 已由 escapeMarkdown 转义
+func escapeLineStart(line string) string {
+	if line == "" {
+		return line
+	}
+	// markerEnds 判断标记后是空格或行尾
+	markerEnds := func(i int) bool {
+		return i == len(line) || line[i] == ' '
+	}
+	switch ch := line[0]; {
+	case ch == '>':
+		return `\` + line
+	case ch == '#':
+		hashes := len(line) - len(strings.TrimLeft(line, "#"))
+		if hashes <= 6 && markerEnds(hashes) {
+			return `\` + line
+		}
+	case ch == '-' || ch == '+' || ch == '=':
+		// "- "、"+ " 是列表，只由 - 或 = 组成的行是分隔线或 setext 标题的下划线
+		if markerEnds(1) || strings.Trim(line, string(ch)+" ") == "" {
+			return `\` + line
+		}
+	case ch >= '0' && ch <= '9':
+		digits := len(line) - len(strings.TrimLeft(line, "0123456789"))
+		if digits <= 9 && digits < len(line) && (line[digits] == '.' || line[digits] == ')') && markerEnds(digits+1) {
+			return line[:digits] + `\` + line[digits:]
+		}
+	}
+	return line
+}
+
+// cleanInline 整理段落文本：去除行首空白并压缩相邻文本节点间的多余空格，保留换行前的两个空格
+func cleanInline(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for i, line := range lines {
+		hardBreak := strings.HasSuffix(line, "  ") && i < len(lines)-1
+		line = strings.TrimSpace(collapseWhitespace(line))
+		if hardBreak {
+			line += "  "
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// collapseWhitespace 将连续空白压缩为单个空格
+func collapseWhitespace(text string) string {
+	var sb strings.Builder
+	space := false
+	for _, r := range text {
+		switch r {
+		case ' ', '\t', '\n', '\r', '\f':
+			if !space {
+				sb.WriteByte(' ')
+				space = true
+			}
+		default:
+			sb.WriteRune(r)
+			space = false
+		}
+	}
+	return sb.String()
+}
+
+// textContent 返回节点的原始文本内容
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		sb.WriteString(textContent(child))
+	}
+	return sb.String()
+}
+
+// prefixLines 为第一行添加 first 前缀，其余非空行添加 rest 前缀
+func prefixLines(text, first, rest string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		switch {
+		case i == 0:
+			lines[i] = first + line
+		case line == "":
+			lines[i] = strings.TrimRight(rest, " ")
+		default:
+			lines[i] = rest + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
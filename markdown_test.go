@@ -0,0 +1,55 @@
+package primp
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files in testdata")
+
+// markdownTestURL 是 golden 测试中响应的 URL，用于解析相对链接
+const markdownTestURL = "https://example.com/docs/page.html"
+
+func TestMarkdownGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "markdown", "*.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no markdown fixtures found")
+	}
+
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".html")
+		t.Run(name, func(t *testing.T) {
+			source, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			doc, err := html.Parse(strings.NewReader(string(source)))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			got := htmlToMarkdown(doc, markdownTestURL)
+
+			golden := strings.TrimSuffix(input, ".html") + ".md"
+			if *updateGolden {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("markdown mismatch for %s\n--- got ---\n%s\n--- want ---\n%s", input, got, want)
+			}
+		})
+	}
+}
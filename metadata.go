@@ -0,0 +1,358 @@
+package primp
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Metadata 表示页面中的结构化元数据
+type Metadata struct {
+	Title        string
+	Description  string
+	CanonicalURL string
+	OpenGraph    OpenGraph
+	Twitter      TwitterCard
+	JSONLD       []map[string]interface{}
+	Microdata    []*MicrodataItem
+}
+
+// OpenGraph 表示 og:* 元数据
+type OpenGraph struct {
+	Title       string
+	Type        string
+	URL         string
+	Description string
+	SiteName    string
+	Locale      string
+	Images      []string
+	Videos      []string
+	// Properties 包含全部 og:* 属性（去掉 og: 前缀），以及 article:*、product:* 等扩展属性
+	Properties map[string][]string
+}
+
+// TwitterCard 表示 twitter:* 元数据
+type TwitterCard struct {
+	Card        string
+	Site        string
+	Creator     string
+	Title       string
+	Description string
+	Image       string
+	// Properties 包含全部 twitter:* 属性（去掉 twitter: 前缀）
+	Properties map[string]string
+}
+
+// MicrodataItem 表示一个 schema.org 微数据项
+type MicrodataItem struct {
+	Type []string
+	ID   string
+	// Properties 的值为 string 或嵌套的 *MicrodataItem
+	Properties map[string][]interface{}
+}
+
+// Metadata 提取页面的标题、描述、规范链接、OpenGraph、Twitter Card、JSON-LD 和微数据
+func (r *Response) Metadata() (*Metadata, error) {
+	doc, err := r.document()
+	if err != nil {
+		return nil, err
+	}
+
+	return extractMetadata(doc, r.URL), nil
+}
+
+// extractMetadata 从文档树中提取元数据
+func extractMetadata(doc *html.Node, baseURL string) *Metadata {
+	base := documentBaseURL(doc, baseURL)
+	meta := &Metadata{
+		OpenGraph: OpenGraph{Properties: make(map[string][]string)},
+		Twitter:   TwitterCard{Properties: make(map[string]string)},
+	}
+	ids := make(map[string]*html.Node)
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if id, ok := getAttr(n, "id"); ok {
+				if _, exists := ids[id]; !exists {
+					ids[id] = n
+				}
+			}
+
+			switch n.DataAtom {
+			case atom.Title:
+				if meta.Title == "" {
+					meta.Title = innerText(n)
+				}
+			case atom.Meta:
+				meta.addMetaTag(n, base)
+			case atom.Link:
+				rel, _ := getAttr(n, "rel")
+				if href, ok := getAttr(n, "href"); ok && meta.CanonicalURL == "" && hasToken(rel, "canonical") {
+					meta.CanonicalURL = resolveURL(base, href)
+				}
+			case atom.Script:
+				if typ, _ := getAttr(n, "type"); strings.EqualFold(strings.TrimSpace(typ), "application/ld+json") {
+					meta.JSONLD = append(meta.JSONLD, parseJSONLD(textContent(n))...)
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+
+	if meta.Description == "" {
+		meta.Description = firstValue(meta.OpenGraph.Properties["description"])
+	}
+	if meta.CanonicalURL == "" {
+		meta.CanonicalURL = meta.OpenGraph.URL
+	}
+	meta.Microdata = extractMicrodata(doc, base, ids)
+	return meta
+}
+
+// addMetaTag 处理单个 meta 标签
+func (m *Metadata) addMetaTag(n *html.Node, base *url.URL) {
+	content, ok := getAttr(n, "content")
+	if !ok {
+		return
+	}
+	content = strings.TrimSpace(content)
+
+	key, ok := getAttr(n, "property")
+	if !ok {
+		key, _ = getAttr(n, "name")
+	}
+	key = strings.ToLower(strings.TrimSpace(key))
+
+	switch {
+	case key == "description":
+		if m.Description == "" {
+			m.Description = content
+		}
+	case strings.HasPrefix(key, "og:"):
+		m.OpenGraph.add(strings.TrimPrefix(key, "og:"), content, base)
+	case strings.HasPrefix(key, "twitter:"):
+		m.Twitter.add(strings.TrimPrefix(key, "twitter:"), content, base)
+	case strings.HasPrefix(key, "article:"), strings.HasPrefix(key, "book:"),
+		strings.HasPrefix(key, "profile:"), strings.HasPrefix(key, "product:"),
+		strings.HasPrefix(key, "music:"), strings.HasPrefix(key, "video:"):
+		m.OpenGraph.Properties[key] = append(m.OpenGraph.Properties[key], content)
+	}
+}
+
+// add 添加一个 OpenGraph 属性
+func (og *OpenGraph) add(key, value string, base *url.URL) {
+	switch key {
+	case "image", "image:url", "image:secure_url", "video", "video:url", "video:secure_url", "url":
+		value = resolveURL(base, value)
+	}
+	og.Properties[key] = append(og.Properties[key], value)
+
+	switch key {
+	case "title":
+		setIfEmpty(&og.Title, value)
+	case "type":
+		setIfEmpty(&og.Type, value)
+	case "url":
+		setIfEmpty(&og.URL, value)
+	case "description":
+		setIfEmpty(&og.Description, value)
+	case "site_name":
+		setIfEmpty(&og.SiteName, value)
+	case "locale":
+		setIfEmpty(&og.Locale, value)
+	case "image", "image:url":
+		og.Images = append(og.Images, value)
+	case "video", "video:url":
+		og.Videos = append(og.Videos, value)
+	}
+}
+
+// add 添加一个 Twitter Card 属性
+func (tc *TwitterCard) add(key, value string, base *url.URL) {
+	if key == "image" || key == "image:src" {
+		value = resolveURL(base, value)
+	}
+	if _, exists := tc.Properties[key]; !exists {
+		tc.Properties[key] = value
+	}
+
+	switch key {
+	case "card":
+		setIfEmpty(&tc.Card, value)
+	case "site":
+		setIfEmpty(&tc.Site, value)
+	case "creator":
+		setIfEmpty(&tc.Creator, value)
+	case "title":
+		setIfEmpty(&tc.Title, value)
+	case "description":
+		setIfEmpty(&tc.Description, value)
+	case "image", "image:src":
+		setIfEmpty(&tc.Image, value)
+	}
+}
+
+// parseJSONLD 解析 JSON-LD 脚本，数组和 @graph 会被展开为多个对象
+func parseJSONLD(text string) []map[string]interface{} {
+	var value interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(text)), &value); err != nil {
+		return nil
+	}
+
+	var objects []map[string]interface{}
+	var collect func(interface{})
+	collect = func(v interface{}) {
+		switch v := v.(type) {
+		case []interface{}:
+			for _, item := range v {
+				collect(item)
+			}
+		case map[string]interface{}:
+			if graph, ok := v["@graph"]; ok && len(v) <= 2 {
+				collect(graph)
+				return
+			}
+			objects = append(objects, v)
+		}
+	}
+	collect(value)
+	return objects
+}
+
+// extractMicrodata 提取所有顶层微数据项
+func extractMicrodata(doc *html.Node, base *url.URL, ids map[string]*html.Node) []*MicrodataItem {
+	var items []*MicrodataItem
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			_, scope := getAttr(n, "itemscope")
+			_, prop := getAttr(n, "itemprop")
+			if scope && !prop {
+				items = append(items, microdataItem(n, base, ids, map[*html.Node]bool{}))
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+	return items
+}
+
+// microdataItem 解析 itemscope 元素，visited 是当前路径上的 itemscope，用于防止 itemref 造成循环
+// 返回时移出 visited，不同属性通过 itemref 引用同一个 itemscope 时各自都能得到该项
+func microdataItem(scope *html.Node, base *url.URL, ids map[string]*html.Node, visited map[*html.Node]bool) *MicrodataItem {
+	visited[scope] = true
+	defer delete(visited, scope)
+	itemType, _ := getAttr(scope, "itemtype")
+	itemID, _ := getAttr(scope, "itemid")
+	item := &MicrodataItem{
+		Type:       strings.Fields(itemType),
+		ID:         strings.TrimSpace(itemID),
+		Properties: make(map[string][]interface{}),
+	}
+
+	var visit func(*html.Node)
+	visit = func(n *html.Node) {
+		if n.Type != html.ElementNode {
+			return
+		}
+		names, hasProp := getAttr(n, "itemprop")
+		_, nestedScope := getAttr(n, "itemscope")
+		if hasProp {
+			var value interface{}
+			if nestedScope {
+				if visited[n] {
+					return
+				}
+				value = microdataItem(n, base, ids, visited)
+			} else {
+				value = microdataValue(n, base)
+			}
+			for _, name := range strings.Fields(names) {
+				item.Properties[name] = append(item.Properties[name], value)
+			}
+		}
+		// 嵌套的 itemscope 拥有自己的属性
+		if !nestedScope {
+			for child := n.FirstChild; child != nil; child = child.NextSibling {
+				visit(child)
+			}
+		}
+	}
+	for child := scope.FirstChild; child != nil; child = child.NextSibling {
+		visit(child)
+	}
+
+	if refs, ok := getAttr(scope, "itemref"); ok {
+		// 引用的元素本身可能是嵌套的 itemscope，由 visit 中对当前路径的 visited 检查防止循环
+		seen := make(map[*html.Node]bool)
+		for _, id := range strings.Fields(refs) {
+			if ref := ids[id]; ref != nil && ref != scope && !seen[ref] {
+				seen[ref] = true
+				visit(ref)
+			}
+		}
+	}
+	return item
+}
+
+// microdataValue 按 HTML 规范返回 itemprop 元素的值
+func microdataValue(n *html.Node, base *url.URL) string {
+	attr := ""
+	switch n.DataAtom {
+	case atom.Meta:
+		attr = "content"
+	case atom.Audio, atom.Embed, atom.Iframe, atom.Img, atom.Source, atom.Track, atom.Video:
+		value, _ := getAttr(n, "src")
+		return resolveURL(base, value)
+	case atom.A, atom.Area, atom.Link:
+		value, _ := getAttr(n, "href")
+		return resolveURL(base, value)
+	case atom.Object:
+		value, _ := getAttr(n, "data")
+		return resolveURL(base, value)
+	case atom.Data, atom.Meter:
+		attr = "value"
+	case atom.Time:
+		attr = "datetime"
+	}
+	if attr != "" {
+		if value, ok := getAttr(n, attr); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+	return innerText(n)
+}
+
+// hasToken 判断以空白分隔的属性值中是否包含指定记号
+func hasToken(value, token string) bool {
+	for _, field := range strings.Fields(value) {
+		if strings.EqualFold(field, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// setIfEmpty 仅在目标为空时赋值
+func setIfEmpty(target *string, value string) {
+	if *target == "" {
+		*target = value
+	}
+}
+
+// firstValue 返回切片中的第一个值
+func firstValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
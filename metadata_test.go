@@ -0,0 +1,235 @@
+package primp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestMicrodataItemrefNestedScope(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body>
+<div itemscope itemtype="https://schema.org/Person" itemref="addr">
+  <span itemprop="name">Ada</span>
+</div>
+<div id="addr" itemprop="address" itemscope itemtype="https://schema.org/PostalAddress">
+  <span itemprop="addressLocality">London</span>
+</div>
+</body></html>`))
+	}))
+	defer server.Close()
+
+	resp, err := NewClient().Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	meta, err := resp.Metadata()
+	if err != nil {
+		t.Fatalf("Metadata() error = %v", err)
+	}
+	if len(meta.Microdata) != 1 {
+		t.Fatalf("got %d microdata items, want 1", len(meta.Microdata))
+	}
+
+	addresses := meta.Microdata[0].Properties["address"]
+	if len(addresses) != 1 {
+		t.Fatalf("address = %v, want one nested item", addresses)
+	}
+	address, ok := addresses[0].(*MicrodataItem)
+	if !ok {
+		t.Fatalf("address is %T, want *MicrodataItem", addresses[0])
+	}
+	if got := address.Properties["addressLocality"]; len(got) != 1 || got[0] != "London" {
+		t.Errorf("addressLocality = %v, want [London]", got)
+	}
+}
+
+// parseMetadata 从 HTML 字符串中提取元数据
+func parseMetadata(t *testing.T, page string) *Metadata {
+	t.Helper()
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("html.Parse() error = %v", err)
+	}
+	return extractMetadata(doc, "https://example.com/blog/post")
+}
+
+// microdataNames 返回 Properties[prop] 中每个嵌套项的 name
+func microdataNames(t *testing.T, item *MicrodataItem, prop string) []string {
+	t.Helper()
+	var names []string
+	for _, value := range item.Properties[prop] {
+		nested, ok := value.(*MicrodataItem)
+		if !ok {
+			t.Fatalf("%s value is %T, want *MicrodataItem", prop, value)
+		}
+		for _, name := range nested.Properties["name"] {
+			names = append(names, name.(string))
+		}
+	}
+	return names
+}
+
+func TestMicrodataNestedScope(t *testing.T) {
+	meta := parseMetadata(t, `<div itemscope itemtype="https://schema.org/Book">
+  <span itemprop="name">Go Book</span>
+  <div itemprop="author" itemscope itemtype="https://schema.org/Person">
+    <span itemprop="name">Ann</span>
+    <a itemprop="url" href="/ann">profile</a>
+  </div>
+</div>`)
+
+	if len(meta.Microdata) != 1 {
+		t.Fatalf("got %d items, want 1", len(meta.Microdata))
+	}
+	book := meta.Microdata[0]
+	if !reflect.DeepEqual(book.Properties["name"], []interface{}{"Go Book"}) {
+		t.Errorf("book name = %v, want only its own name", book.Properties["name"])
+	}
+	if _, ok := book.Properties["url"]; ok {
+		t.Error("nested item property leaked into the outer item")
+	}
+	author := book.Properties["author"][0].(*MicrodataItem)
+	if !reflect.DeepEqual(author.Type, []string{"https://schema.org/Person"}) {
+		t.Errorf("author type = %v", author.Type)
+	}
+	if !reflect.DeepEqual(author.Properties["url"], []interface{}{"https://example.com/ann"}) {
+		t.Errorf("author url = %v, want the resolved link", author.Properties["url"])
+	}
+}
+
+func TestMicrodataSharedReference(t *testing.T) {
+	meta := parseMetadata(t, `<div itemscope itemtype="https://schema.org/Book" itemref="author editor"></div>
+<div id="author" itemprop="author" itemscope itemref="org"><span itemprop="name">Ann</span></div>
+<div id="editor" itemprop="editor" itemscope itemref="org"><span itemprop="name">Bob</span></div>
+<div id="org" itemprop="affiliation" itemscope><span itemprop="name">ACME</span></div>`)
+
+	if len(meta.Microdata) != 1 {
+		t.Fatalf("got %d items, want 1", len(meta.Microdata))
+	}
+	book := meta.Microdata[0]
+	for _, prop := range []string{"author", "editor"} {
+		people := book.Properties[prop]
+		if len(people) != 1 {
+			t.Fatalf("%s = %v, want one item", prop, people)
+		}
+		// 两个属性引用同一个 itemscope，两边都应包含该项
+		if got := microdataNames(t, people[0].(*MicrodataItem), "affiliation"); !reflect.DeepEqual(got, []string{"ACME"}) {
+			t.Errorf("%s affiliation = %v, want [ACME]", prop, got)
+		}
+	}
+}
+
+func TestMicrodataItemrefCycle(t *testing.T) {
+	meta := parseMetadata(t, `<div itemscope itemref="a self" id="self"><span itemprop="name">Root</span></div>
+<div id="a" itemprop="knows" itemscope itemref="b"><span itemprop="name">A</span></div>
+<div id="b" itemprop="knows" itemscope itemref="a"><span itemprop="name">B</span></div>`)
+
+	if len(meta.Microdata) != 1 {
+		t.Fatalf("got %d items, want 1", len(meta.Microdata))
+	}
+	root := meta.Microdata[0]
+	if got := microdataNames(t, root, "knows"); !reflect.DeepEqual(got, []string{"A"}) {
+		t.Fatalf("root knows = %v, want [A]", got)
+	}
+	a := root.Properties["knows"][0].(*MicrodataItem)
+	if got := microdataNames(t, a, "knows"); !reflect.DeepEqual(got, []string{"B"}) {
+		t.Fatalf("a knows = %v, want [B]", got)
+	}
+	// b 引用回 a 形成循环，a 已在当前路径上，不再展开
+	b := a.Properties["knows"][0].(*MicrodataItem)
+	if knows := b.Properties["knows"]; len(knows) != 0 {
+		t.Errorf("b knows = %v, want the cycle broken", knows)
+	}
+}
+
+func TestMetadataOpenGraphAndTwitter(t *testing.T) {
+	meta := parseMetadata(t, `<html><head>
+<title>Page Title</title>
+<meta property="og:title" content=" OG Title ">
+<meta property="og:title" content="Second OG Title">
+<meta property="og:url" content="/blog/post?ref=og">
+<meta property="og:description" content="From OpenGraph">
+<meta property="og:image" content="/img/one.png">
+<meta property="og:image:width" content="1200">
+<meta property="og:image" content="https://cdn.example.com/two.png">
+<meta property="og:video:url" content="/v.mp4">
+<meta property="article:tag" content="go">
+<meta property="article:tag" content="http">
+<meta name="twitter:card" content="summary_large_image">
+<meta name="twitter:site" content="@example">
+<meta name="twitter:image:src" content="/img/card.png">
+<meta name="twitter:site" content="@ignored">
+<meta property="og:locale">
+</head><body></body></html>`)
+
+	og := meta.OpenGraph
+	if og.Title != "OG Title" || !reflect.DeepEqual(og.Properties["title"], []string{"OG Title", "Second OG Title"}) {
+		t.Errorf("og title = %q, properties %v", og.Title, og.Properties["title"])
+	}
+	wantImages := []string{"https://example.com/img/one.png", "https://cdn.example.com/two.png"}
+	if !reflect.DeepEqual(og.Images, wantImages) {
+		t.Errorf("og images = %v, want %v", og.Images, wantImages)
+	}
+	if !reflect.DeepEqual(og.Videos, []string{"https://example.com/v.mp4"}) {
+		t.Errorf("og videos = %v", og.Videos)
+	}
+	if !reflect.DeepEqual(og.Properties["image:width"], []string{"1200"}) {
+		t.Errorf("og image:width = %v", og.Properties["image:width"])
+	}
+	if !reflect.DeepEqual(og.Properties["article:tag"], []string{"go", "http"}) {
+		t.Errorf("article:tag = %v", og.Properties["article:tag"])
+	}
+	if _, ok := og.Properties["locale"]; ok {
+		t.Error("meta without content should be ignored")
+	}
+
+	// 没有 description 和 canonical 时使用 OpenGraph 的值
+	if meta.Description != "From OpenGraph" {
+		t.Errorf("Description = %q, want the og:description fallback", meta.Description)
+	}
+	if meta.CanonicalURL != "https://example.com/blog/post?ref=og" {
+		t.Errorf("CanonicalURL = %q, want the og:url fallback", meta.CanonicalURL)
+	}
+	if meta.Title != "Page Title" {
+		t.Errorf("Title = %q", meta.Title)
+	}
+
+	tc := meta.Twitter
+	if tc.Card != "summary_large_image" || tc.Site != "@example" || tc.Properties["site"] != "@example" {
+		t.Errorf("twitter = %+v, want the first card and site", tc)
+	}
+	if tc.Image != "https://example.com/img/card.png" {
+		t.Errorf("twitter image = %q, want image:src resolved", tc.Image)
+	}
+}
+
+func TestMetadataJSONLD(t *testing.T) {
+	meta := parseMetadata(t, `<html><head>
+<script type="application/ld+json">{"@type": "Article", "headline": "One"}</script>
+<script type="application/ld+json">[{"@type": "Person"}, {"@type": "Organization"}]</script>
+<script type="application/ld+json">{"@context": "https://schema.org", "@graph": [{"@type": "WebSite"}, [{"@type": "WebPage"}]]}</script>
+<script type="application/ld+json">{"@context": "https://schema.org", "@graph": [{"@type": "Kept"}], "name": "graph with data"}</script>
+<script type="application/ld+json">{"@type": "Broken",</script>
+<script type="APPLICATION/LD+JSON">  "just a string"  </script>
+<script type="text/javascript">{"@type": "NotLD"}</script>
+</head><body></body></html>`)
+
+	var types []interface{}
+	for _, object := range meta.JSONLD {
+		types = append(types, object["@type"])
+	}
+	// 对象中除 @context 外还有其他字段时保留原对象，不展开 @graph
+	want := []interface{}{"Article", "Person", "Organization", "WebSite", "WebPage", nil}
+	if !reflect.DeepEqual(types, want) {
+		t.Fatalf("JSON-LD types = %v, want %v", types, want)
+	}
+	if meta.JSONLD[5]["name"] != "graph with data" {
+		t.Errorf("last object = %v, want the graph object kept whole", meta.JSONLD[5])
+	}
+}
@@ -0,0 +1,153 @@
+package primp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// RoundTripFunc 发送请求并返回响应
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+// Middleware 包装 RoundTripFunc，可以修改请求、检查响应，或者不调用 next 直接返回响应
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// Use 添加中间件，先添加的中间件位于外层
+// 中间件看到的是已设置好模拟浏览器头部、认证和请求体的 http.Request，每个逻辑请求调用一次（重定向在内部处理）
+func (c *Client) Use(middlewares ...Middleware) {
+	c.middlewares = append(c.middlewares, middlewares...)
+}
+
+// BeforeRequest 创建在发送前调用 fn 的中间件，fn 返回错误时中止请求
+func BeforeRequest(fn func(req *http.Request) error) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			if err := fn(req); err != nil {
+				return nil, err
+			}
+			return next(req)
+		}
+	}
+}
+
+// AfterResponse 创建在收到响应后调用 fn 的中间件，fn 返回错误时关闭响应并返回该错误
+func AfterResponse(fn func(resp *http.Response) error) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			resp, err := next(req)
+			if err != nil {
+				return resp, err
+			}
+			if err := fn(resp); err != nil {
+				resp.Body.Close()
+				return nil, err
+			}
+			return resp, nil
+		}
+	}
+}
+
+// NewSyntheticResponse 创建不经过网络的响应，供中间件短路请求时返回
+func NewSyntheticResponse(req *http.Request, statusCode int, header http.Header, body []byte) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// baseTransport 返回底层使用的 RoundTripper
+func (c *Client) baseTransport() http.RoundTripper {
+	if c.httpClient.Transport != nil {
+		return c.httpClient.Transport
+	}
+	return http.DefaultTransport
+}
+
+// roundTrip 通过中间件链发送请求
+// http.Client.Do 总会关闭请求体，中间件出错或不经过网络直接返回响应时由这里关闭，保证流式请求体不会泄漏
+func (c *Client) roundTrip(req *http.Request) (*http.Response, error) {
+	do := c.httpClient.Do
+	if c.replayer != nil || c.harRecorder != nil {
+		// 在传输层回放和记录，每次重定向都会单独匹配和生成条目
+		transport := c.baseTransport()
+		if c.replayer != nil {
+			transport = c.replayer.transport(transport)
+		}
+		if c.harRecorder != nil {
+			transport = c.harRecorder.transport(transport)
+		}
+		httpClient := *c.httpClient
+		httpClient.Transport = transport
+		do = httpClient.Do
+	}
+	sent := false
+	next := RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent = true
+		handshake := &handshakeTrace{}
+		resp, err := do(handshake.withHandshakeTrace(req))
+		if err != nil {
+			err = handshake.wrap(err)
+		}
+		return resp, err
+	})
+	if c.logger != nil {
+		next = c.logRoundTrip(next)
+	}
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		next = c.middlewares[i](next)
+	}
+	next = completeResponse(next)
+	if c.telemetry != nil {
+		next = c.telemetry.roundTrip(c, next)
+	}
+
+	resp, err := next(req)
+	if (err != nil || !sent) && req.Body != nil {
+		req.Body.Close()
+	}
+	if err != nil {
+		if resp != nil && resp.Body != nil {
+			resp.Body.Close()
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+// completeResponse 补全中间件构造的响应中缺少的字段，位于 telemetry 之内，使外层总能拿到完整的响应
+func completeResponse(next RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		resp, err := next(req)
+		if err != nil {
+			return resp, err
+		}
+		if resp == nil {
+			return nil, fmt.Errorf("middleware returned no response")
+		}
+
+		if resp.Request == nil {
+			resp.Request = req
+		}
+		if resp.Body == nil {
+			resp.Body = http.NoBody
+		}
+		if resp.Header == nil {
+			resp.Header = http.Header{}
+		}
+		if resp.Status == "" {
+			resp.Status = fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+		}
+		return resp, nil
+	}
+}
@@ -0,0 +1,61 @@
+package primp
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// closeRecorder 记录请求体是否被关闭，Close 可能在写入请求体的 goroutine 中调用
+type closeRecorder struct {
+	*strings.Reader
+	closed atomic.Bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed.Store(true)
+	return nil
+}
+
+func TestMiddlewareClosesUnsentBody(t *testing.T) {
+	errRejected := errors.New("rejected")
+	tests := []struct {
+		name       string
+		middleware Middleware
+		wantErr    bool
+	}{
+		{
+			name: "error",
+			middleware: BeforeRequest(func(req *http.Request) error {
+				return errRejected
+			}),
+			wantErr: true,
+		},
+		{
+			name: "synthetic response",
+			middleware: func(next RoundTripFunc) RoundTripFunc {
+				return func(req *http.Request) (*http.Response, error) {
+					return NewSyntheticResponse(req, http.StatusOK, nil, []byte("cached")), nil
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := &closeRecorder{Reader: strings.NewReader("payload")}
+			client := NewClient(WithMiddleware(tt.middleware))
+			_, err := client.Post("http://example.invalid/", RequestParams{Body: body})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Post() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, errRejected) {
+				t.Errorf("Post() error = %v, want %v", err, errRejected)
+			}
+			if !body.closed.Load() {
+				t.Error("request body not closed")
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package primp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Multipart 是 multipart/form-data 请求体构建器，发送时通过 io.Pipe 流式写出，不会将文件读入内存
+// 使用 io.Reader 的部分只能发送一次，文件路径和字节数据可以重复发送
+type Multipart struct {
+	parts    []*multipartPart
+	boundary string
+}
+
+// multipartPart 表示请求体中的一个部分
+type multipartPart struct {
+	name     string
+	filename string
+	header   textproto.MIMEHeader
+	isFile   bool
+
+	value  []byte
+	path   string
+	reader io.Reader
+	// size 为 -1 表示长度未知
+	size int64
+}
+
+// PartOption 是配置文件部分的函数类型
+type PartOption func(*multipartPart)
+
+// PartFilename 设置文件部分的文件名
+func PartFilename(filename string) PartOption {
+	return func(p *multipartPart) {
+		p.filename = filename
+	}
+}
+
+// PartContentType 设置文件部分的 Content-Type，默认根据扩展名推断
+func PartContentType(contentType string) PartOption {
+	return func(p *multipartPart) {
+		p.header.Set("Content-Type", contentType)
+	}
+}
+
+// PartHeader 为部分添加额外的头部
+func PartHeader(key, value string) PartOption {
+	return func(p *multipartPart) {
+		p.header.Add(key, value)
+	}
+}
+
+// PartSize 设置 io.Reader 的长度，使请求可以带上 Content-Length
+func PartSize(size int64) PartOption {
+	return func(p *multipartPart) {
+		p.size = size
+	}
+}
+
+// NewMultipart 创建一个空的 multipart 构建器
+func NewMultipart() *Multipart {
+	return &Multipart{boundary: multipart.NewWriter(nil).Boundary()}
+}
+
+// AddField 添加普通文本字段
+func (m *Multipart) AddField(name, value string) *Multipart {
+	m.parts = append(m.parts, &multipartPart{
+		name:   name,
+		header: textproto.MIMEHeader{},
+		value:  []byte(value),
+		size:   int64(len(value)),
+	})
+	return m
+}
+
+// AddFile 添加本地文件，文件在发送时才打开
+func (m *Multipart) AddFile(name, path string, opts ...PartOption) *Multipart {
+	part := &multipartPart{name: name, filename: filepath.Base(path), header: textproto.MIMEHeader{}, isFile: true, path: path, size: -1}
+	return m.addPart(part, opts)
+}
+
+// AddReader 添加从 io.Reader 读取的文件内容，如果 r 实现了 io.Closer，发送后会被关闭
+func (m *Multipart) AddReader(name, filename string, r io.Reader, opts ...PartOption) *Multipart {
+	part := &multipartPart{name: name, filename: filename, header: textproto.MIMEHeader{}, isFile: true, reader: r, size: -1}
+	if lr, ok := r.(interface{ Len() int }); ok {
+		part.size = int64(lr.Len())
+	}
+	return m.addPart(part, opts)
+}
+
+// AddBytes 添加内存中的文件内容
+func (m *Multipart) AddBytes(name, filename string, data []byte, opts ...PartOption) *Multipart {
+	part := &multipartPart{name: name, filename: filename, header: textproto.MIMEHeader{}, isFile: true, value: data, size: int64(len(data))}
+	return m.addPart(part, opts)
+}
+
+// addPart 应用选项并补全文件部分的头部
+func (m *Multipart) addPart(part *multipartPart, opts []PartOption) *Multipart {
+	for _, opt := range opts {
+		opt(part)
+	}
+	if part.header.Get("Content-Type") == "" {
+		contentType := mime.TypeByExtension(filepath.Ext(part.filename))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		part.header.Set("Content-Type", contentType)
+	}
+	m.parts = append(m.parts, part)
+	return m
+}
+
+// ContentType 返回包含 boundary 的 Content-Type
+func (m *Multipart) ContentType() string {
+	return "multipart/form-data; boundary=" + m.boundary
+}
+
+// partHeader 返回部分的完整头部
+func (p *multipartPart) partHeader() textproto.MIMEHeader {
+	header := make(textproto.MIMEHeader, len(p.header)+1)
+	for k, v := range p.header {
+		header[k] = v
+	}
+	disposition := fmt.Sprintf(`form-data; name="%s"`, escapeQuotes(p.name))
+	if p.isFile {
+		disposition += fmt.Sprintf(`; filename="%s"`, escapeQuotes(p.filename))
+	}
+	header.Set("Content-Disposition", disposition)
+	return header
+}
+
+// contentLength 计算请求体总长度，任一部分长度未知时返回 -1
+func (m *Multipart) contentLength() (int64, error) {
+	var total int64
+	for _, part := range m.parts {
+		if part.path != "" {
+			info, err := os.Stat(part.path)
+			if err != nil {
+				return 0, fmt.Errorf("failed to open file %s: %w", part.path, err)
+			}
+			part.size = info.Size()
+		}
+		if part.size < 0 {
+			return -1, nil
+		}
+		total += part.size
+	}
+
+	// 分隔符和头部与内容无关，写入计数器即可得到其长度
+	counter := &countingWriter{}
+	w := multipart.NewWriter(counter)
+	if err := w.SetBoundary(m.boundary); err != nil {
+		return 0, fmt.Errorf("invalid multipart boundary: %w", err)
+	}
+	for _, part := range m.parts {
+		if _, err := w.CreatePart(part.partHeader()); err != nil {
+			return 0, fmt.Errorf("failed to create form part: %w", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return 0, fmt.Errorf("failed to close multipart writer: %w", err)
+	}
+	return total + counter.n, nil
+}
+
+// reader 返回流式的请求体及其长度，长度未知时为 -1
+// 写入协程在请求体被读完、关闭或 ctx 结束时退出
+func (m *Multipart) reader(ctx context.Context) (io.ReadCloser, int64, error) {
+	size, err := m.contentLength()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		stop := context.AfterFunc(ctx, func() {
+			pw.CloseWithError(ctx.Err())
+		})
+		defer stop()
+		pw.CloseWithError(m.writeTo(pw))
+	}()
+	return pr, size, nil
+}
+
+// writeTo 将所有部分写入 w，无论是否写完都会关闭 io.Reader 来源
+func (m *Multipart) writeTo(w io.Writer) error {
+	defer m.closeReaders()
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(m.boundary); err != nil {
+		return fmt.Errorf("invalid multipart boundary: %w", err)
+	}
+	for _, part := range m.parts {
+		pw, err := mw.CreatePart(part.partHeader())
+		if err != nil {
+			return fmt.Errorf("failed to create form part: %w", err)
+		}
+		if err := part.copyTo(pw); err != nil {
+			return err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return fmt.Errorf("failed to close multipart writer: %w", err)
+	}
+	return nil
+}
+
+// copyTo 写出部分的内容
+func (p *multipartPart) copyTo(w io.Writer) error {
+	var src io.Reader
+	switch {
+	case p.path != "":
+		file, err := os.Open(p.path)
+		if err != nil {
+			return fmt.Errorf("failed to open file %s: %w", p.path, err)
+		}
+		defer file.Close()
+		src = file
+	case p.reader != nil:
+		src = p.reader
+	default:
+		src = bytes.NewReader(p.value)
+	}
+
+	n, err := io.Copy(w, src)
+	if err != nil {
+		return fmt.Errorf("failed to copy file content: %w", err)
+	}
+	if p.size >= 0 && n != p.size {
+		return fmt.Errorf("form part %q: expected %d bytes, got %d", p.name, p.size, n)
+	}
+	return nil
+}
+
+// closeReaders 关闭实现了 io.Closer 的 io.Reader 来源
+func (m *Multipart) closeReaders() {
+	for _, part := range m.parts {
+		if closer, ok := part.reader.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+}
+
+// countingWriter 只统计写入的字节数
+type countingWriter struct {
+	n int64
+}
+
+// Write 实现 io.Writer 接口
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
+
+// quoteEscaper 转义 Content-Disposition 中的引号和反斜杠
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// escapeQuotes 转义参数值
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
+
+// withFields 返回在开头加入表单字段的副本，不修改原构建器
+func (m *Multipart) withFields(fields [][2]string) *Multipart {
+	if len(fields) == 0 {
+		return m
+	}
+	form := &Multipart{boundary: m.boundary}
+	for _, pair := range fields {
+		form.AddField(pair[0], pair[1])
+	}
+	form.parts = append(form.parts, m.parts...)
+	return form
+}
+
+// dataPairs 将 Data 展开为字段名和值，字段按名称排序，切片值展开为同名的多个字段并保持顺序
+func dataPairs(data map[string]interface{}) [][2]string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var pairs [][2]string
+	for _, k := range keys {
+		switch v := data[k].(type) {
+		case []string:
+			for _, item := range v {
+				pairs = append(pairs, [2]string{k, item})
+			}
+		case []interface{}:
+			for _, item := range v {
+				pairs = append(pairs, [2]string{k, fmt.Sprintf("%v", item)})
+			}
+		default:
+			pairs = append(pairs, [2]string{k, fmt.Sprintf("%v", v)})
+		}
+	}
+	return pairs
+}
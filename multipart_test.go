@@ -0,0 +1,41 @@
+package primp
+
+import (
+	"errors"
+	"net/http"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMultipartRejectedRequestReleasesWriter(t *testing.T) {
+	errRejected := errors.New("rejected")
+	client := NewClient(WithMiddleware(BeforeRequest(func(req *http.Request) error {
+		return errRejected
+	})))
+
+	before := runtime.NumGoroutine()
+	var sources []*closeRecorder
+	for i := 0; i < 20; i++ {
+		source := &closeRecorder{Reader: strings.NewReader("file content")}
+		sources = append(sources, source)
+		form := NewMultipart().AddField("name", "value").AddReader("file", "a.txt", source)
+		if _, err := client.Post("http://example.invalid/", RequestParams{Multipart: form}); !errors.Is(err, errRejected) {
+			t.Fatalf("Post() error = %v, want %v", err, errRejected)
+		}
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("goroutines = %d after rejected uploads, want at most %d", after, before)
+	}
+	for i, source := range sources {
+		if !source.closed.Load() {
+			t.Errorf("source %d not closed", i)
+		}
+	}
+}
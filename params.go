@@ -0,0 +1,115 @@
+package primp
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ParamsPolicy 决定客户端参数与请求参数同名时的处理方式
+type ParamsPolicy int
+
+const (
+	// ParamsRequestFirst 请求参数（包括 URL 中已有的参数）覆盖同名的客户端参数，这是默认行为
+	ParamsRequestFirst ParamsPolicy = iota
+	// ParamsClientFirst 客户端参数覆盖同名的请求参数
+	ParamsClientFirst
+	// ParamsAppend 保留所有参数，同名参数会重复出现
+	ParamsAppend
+)
+
+// PairsFromValues 将 url.Values 转换为有序的键值对，键按名称排序，同名的值保持原顺序
+func PairsFromValues(values url.Values) [][2]string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var pairs [][2]string
+	for _, k := range keys {
+		for _, v := range values[k] {
+			pairs = append(pairs, [2]string{k, v})
+		}
+	}
+	return pairs
+}
+
+// sortedPairs 将 map 转换为按键排序的键值对
+func sortedPairs(m map[string]string) [][2]string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([][2]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, [2]string{k, m[k]})
+	}
+	return pairs
+}
+
+// queryPart 表示查询字符串中的一项，raw 为编码后的原始文本
+type queryPart struct {
+	key string
+	raw string
+}
+
+// buildQuery 依次合并 URL 中已有的参数、请求参数和客户端参数
+// URL 中已有的参数保持原有顺序和编码，同名参数按 ParamsPolicy 处理
+func (c *Client) buildQuery(rawQuery string, params RequestParams) string {
+	var requestParts []queryPart
+	for _, segment := range strings.Split(rawQuery, "&") {
+		if segment == "" {
+			continue
+		}
+		key, _, _ := strings.Cut(segment, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+		requestParts = append(requestParts, queryPart{key: key, raw: segment})
+	}
+	for _, pair := range append(sortedPairs(params.Params), params.ParamPairs...) {
+		requestParts = append(requestParts, newQueryPart(pair))
+	}
+
+	var clientParts []queryPart
+	for _, pair := range sortedPairs(c.params) {
+		clientParts = append(clientParts, newQueryPart(pair))
+	}
+
+	switch c.paramsPolicy {
+	case ParamsRequestFirst:
+		clientParts = withoutKeys(clientParts, requestParts)
+	case ParamsClientFirst:
+		requestParts = withoutKeys(requestParts, clientParts)
+	}
+
+	segments := make([]string, 0, len(requestParts)+len(clientParts))
+	for _, part := range append(requestParts, clientParts...) {
+		segments = append(segments, part.raw)
+	}
+	return strings.Join(segments, "&")
+}
+
+// newQueryPart 编码一个键值对
+func newQueryPart(pair [2]string) queryPart {
+	return queryPart{key: pair[0], raw: url.QueryEscape(pair[0]) + "=" + url.QueryEscape(pair[1])}
+}
+
+// withoutKeys 移除 parts 中与 other 同名的项
+func withoutKeys(parts, other []queryPart) []queryPart {
+	keys := make(map[string]bool, len(other))
+	for _, part := range other {
+		keys[part.key] = true
+	}
+
+	var kept []queryPart
+	for _, part := range parts {
+		if !keys[part.key] {
+			kept = append(kept, part)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,120 @@
+package primp
+
+import (
+	"io"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestBuildQueryPolicies(t *testing.T) {
+	clientParams := map[string]string{"key": "client", "lang": "en"}
+	requestParams := RequestParams{Params: map[string]string{"key": "request"}}
+	tests := []struct {
+		name   string
+		policy ParamsPolicy
+		url    string
+		want   string
+	}{
+		{"request first", ParamsRequestFirst, "https://example.com/?page=2", "page=2&key=request&lang=en"},
+		{"request first keeps url params", ParamsRequestFirst, "https://example.com/?lang=de", "lang=de&key=request"},
+		{"client first", ParamsClientFirst, "https://example.com/?page=2&lang=de", "page=2&key=client&lang=en"},
+		{"append", ParamsAppend, "https://example.com/?key=url", "key=url&key=request&key=client&lang=en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient(WithParams(clientParams), WithParamsPolicy(tt.policy))
+			prepared, err := client.Prepare(GET, tt.url, requestParams)
+			if err != nil {
+				t.Fatalf("Prepare() error = %v", err)
+			}
+			if got := prepared.Request.URL.RawQuery; got != tt.want {
+				t.Errorf("RawQuery = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildQueryOrderAndEncoding(t *testing.T) {
+	client := NewClient()
+	prepared, err := client.Prepare(GET, "https://example.com/?b=%2F&a=1", RequestParams{
+		Params:     map[string]string{"z": "last", "m": "a b"},
+		ParamPairs: [][2]string{{"tag", "x"}, {"tag", "y"}, {"a", "2"}},
+	})
+	if err != nil {
+		t.Fatalf("Prepare() error = %v", err)
+	}
+	// URL 中的参数保持原编码，Params 按键排序，ParamPairs 保持顺序和重复的键
+	want := "b=%2F&a=1&m=a+b&z=last&tag=x&tag=y&a=2"
+	if got := prepared.Request.URL.RawQuery; got != want {
+		t.Errorf("RawQuery = %q, want %q", got, want)
+	}
+}
+
+func TestPairsFromValues(t *testing.T) {
+	values := url.Values{"b": {"2", "1"}, "a": {"x"}}
+	want := [][2]string{{"a", "x"}, {"b", "2"}, {"b", "1"}}
+	if got := PairsFromValues(values); !reflect.DeepEqual(got, want) {
+		t.Errorf("PairsFromValues() = %q, want %q", got, want)
+	}
+	if got := PairsFromValues(nil); got != nil {
+		t.Errorf("PairsFromValues(nil) = %q, want nil", got)
+	}
+}
+
+func TestFormBodyPairs(t *testing.T) {
+	tests := []struct {
+		name   string
+		params RequestParams
+		want   string
+	}{
+		{
+			name:   "data sorted by key",
+			params: RequestParams{Data: map[string]interface{}{"b": "2", "a": 1}},
+			want:   "a=1&b=2",
+		},
+		{
+			name:   "string slice repeats the key",
+			params: RequestParams{Data: map[string]interface{}{"tag": []string{"x", "y"}}},
+			want:   "tag=x&tag=y",
+		},
+		{
+			name:   "interface slice repeats the key",
+			params: RequestParams{Data: map[string]interface{}{"n": []interface{}{1, true}}},
+			want:   "n=1&n=true",
+		},
+		{
+			name:   "data pairs keep order and duplicates",
+			params: RequestParams{DataPairs: [][2]string{{"z", "1"}, {"a", "2"}, {"z", "3"}}},
+			want:   "z=1&a=2&z=3",
+		},
+		{
+			name: "data pairs follow data",
+			params: RequestParams{
+				Data:      map[string]interface{}{"m": "a b"},
+				DataPairs: [][2]string{{"a", "&"}},
+			},
+			want: "m=a+b&a=%26",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prepared, err := NewClient().Prepare(POST, "https://example.com/", tt.params)
+			if err != nil {
+				t.Fatalf("Prepare() error = %v", err)
+			}
+			if ct := prepared.Request.Header.Get("Content-Type"); ct != "application/x-www-form-urlencoded" {
+				t.Errorf("Content-Type = %q, want form encoding", ct)
+			}
+			body, err := io.ReadAll(prepared.Request.Body)
+			if err != nil {
+				t.Fatalf("ReadAll() error = %v", err)
+			}
+			if string(body) != tt.want {
+				t.Errorf("body = %q, want %q", body, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package primp
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// nonRenderedElements 是浏览器不会渲染为文本的元素
+var nonRenderedElements = map[atom.Atom]bool{
+	atom.Head:     true,
+	atom.Title:    true,
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Svg:      true,
+	atom.Canvas:   true,
+	atom.Iframe:   true,
+	atom.Object:   true,
+	atom.Select:   true,
+	atom.Input:    true,
+	atom.Textarea: true,
+}
+
+// paragraphElements 是前后需要空行分隔的块级元素
+var paragraphElements = map[atom.Atom]bool{
+	atom.P: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Blockquote: true, atom.Pre: true, atom.Table: true, atom.Figure: true, atom.Hr: true,
+}
+
+// isHiddenElement 判断元素是否被 hidden、aria-hidden 或内联样式隐藏
+func isHiddenElement(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	if _, ok := getAttr(n, "hidden"); ok {
+		return true
+	}
+	if ariaHidden, _ := getAttr(n, "aria-hidden"); strings.EqualFold(strings.TrimSpace(ariaHidden), "true") {
+		return true
+	}
+	if style, ok := getAttr(n, "style"); ok {
+		style = strings.ToLower(strings.Join(strings.Fields(style), ""))
+		if strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden") {
+			return true
+		}
+	}
+	return false
+}
+
+// htmlToPlainText 将 HTML 转换为保留版面结构的纯文本
+func htmlToPlainText(doc *html.Node) string {
+	w := &plainTextWriter{}
+	w.walk(doc)
+	if w.sb.Len() == 0 {
+		return ""
+	}
+	return w.sb.String() + "\n"
+}
+
+// plainTextWriter 按 CSS 的空白处理规则输出文本
+type plainTextWriter struct {
+	sb       strings.Builder
+	newlines int
+	space    bool
+	sep      string
+	indent   string
+	prefix   string
+	started  bool
+	atLine   bool
+	pre      int
+}
+
+// breakLines 要求在下一段内容之前至少有 n 个换行
+func (w *plainTextWriter) breakLines(n int) {
+	if n > w.newlines {
+		w.newlines = n
+	}
+}
+
+// separate 在同一行的下一段内容之前插入分隔符
+func (w *plainTextWriter) separate(sep string) {
+	w.sep = sep
+}
+
+// flush 输出待处理的换行、缩进和空白
+func (w *plainTextWriter) flush() {
+	switch {
+	case !w.started:
+		w.started = true
+		w.atLine = true
+		w.sb.WriteString(w.linePrefix())
+	case w.newlines > 0:
+		w.sb.WriteString(strings.Repeat("\n", w.newlines))
+		w.sb.WriteString(w.linePrefix())
+		w.atLine = true
+	case w.sep != "" && !w.atLine:
+		w.sb.WriteString(w.sep)
+	case w.space && !w.atLine:
+		w.sb.WriteByte(' ')
+	}
+	w.newlines = 0
+	w.space = false
+	w.sep = ""
+}
+
+// linePrefix 返回新行的前缀，列表项的首行使用项目符号
+func (w *plainTextWriter) linePrefix() string {
+	if w.prefix != "" {
+		prefix := w.prefix
+		w.prefix = ""
+		return prefix
+	}
+	return w.indent
+}
+
+// writeText 输出一个文本节点
+func (w *plainTextWriter) writeText(text string) {
+	if w.pre > 0 {
+		for i, line := range strings.Split(text, "\n") {
+			if i > 0 && w.started {
+				w.newlines++
+			}
+			if line != "" {
+				w.flush()
+				w.sb.WriteString(line)
+				w.atLine = false
+			}
+		}
+		return
+	}
+
+	collapsed := collapseWhitespace(text)
+	trimmed := strings.TrimSpace(collapsed)
+	if trimmed == "" {
+		if collapsed != "" && w.started {
+			w.space = true
+		}
+		return
+	}
+	if strings.HasPrefix(collapsed, " ") {
+		w.space = true
+	}
+
+	w.flush()
+	w.sb.WriteString(trimmed)
+	w.atLine = false
+	if strings.HasSuffix(collapsed, " ") {
+		w.space = true
+	}
+}
+
+// walk 遍历节点并输出文本
+func (w *plainTextWriter) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.writeText(n.Data)
+		return
+	case html.DocumentNode:
+		w.walkChildren(n)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+	if nonRenderedElements[n.DataAtom] || isHiddenElement(n) {
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		if w.started {
+			w.newlines++
+		}
+		return
+	case atom.Hr:
+		w.breakLines(2)
+		w.flush()
+		w.sb.WriteString("----")
+		w.atLine = false
+		w.breakLines(2)
+		return
+	case atom.Img:
+		return
+	case atom.Pre:
+		w.breakLines(2)
+		w.pre++
+		w.walkChildren(n)
+		w.pre--
+		w.breakLines(2)
+		return
+	case atom.Ul, atom.Ol:
+		w.list(n)
+		return
+	case atom.Tr:
+		w.breakLines(1)
+		w.walkChildren(n)
+		w.breakLines(1)
+		return
+	case atom.Td, atom.Th:
+		w.separate("\t")
+		w.walkChildren(n)
+		w.separate("\t")
+		return
+	}
+
+	switch {
+	case paragraphElements[n.DataAtom]:
+		w.breakLines(2)
+		w.walkChildren(n)
+		w.breakLines(2)
+	case isBlock(n):
+		w.breakLines(1)
+		w.walkChildren(n)
+		w.breakLines(1)
+	default:
+		w.walkChildren(n)
+	}
+}
+
+// walkChildren 遍历所有子节点
+func (w *plainTextWriter) walkChildren(n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		w.walk(child)
+	}
+}
+
+// list 输出列表，每项前添加项目符号或序号并按嵌套层级缩进
+func (w *plainTextWriter) list(n *html.Node) {
+	ordered := n.DataAtom == atom.Ol
+	index := 1
+	if start, ok := getAttr(n, "start"); ok {
+		if v, err := strconv.Atoi(strings.TrimSpace(start)); err == nil {
+			index = v
+		}
+	}
+
+	parentIndent := w.indent
+	w.breakLines(1)
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || child.DataAtom != atom.Li || isHiddenElement(child) {
+			w.walk(child)
+			continue
+		}
+
+		marker := "• "
+		if ordered {
+			marker = strconv.Itoa(index) + ". "
+			index++
+		}
+		w.breakLines(1)
+		w.prefix = parentIndent + marker
+		w.indent = parentIndent + strings.Repeat(" ", len([]rune(marker)))
+		w.walkChildren(child)
+		w.prefix = ""
+		w.indent = parentIndent
+		w.breakLines(1)
+	}
+}
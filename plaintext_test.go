@@ -0,0 +1,49 @@
+package primp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestPlainTextGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "plaintext", "*.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no plain text fixtures found")
+	}
+
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".html")
+		t.Run(name, func(t *testing.T) {
+			source, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			doc, err := html.Parse(strings.NewReader(string(source)))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			got := htmlToPlainText(doc)
+
+			golden := strings.TrimSuffix(input, ".html") + ".txt"
+			if *updateGolden {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("plain text mismatch for %s\n--- got ---\n%s\n--- want ---\n%s", input, got, want)
+			}
+		})
+	}
+}
@@ -2,10 +2,13 @@
 package primp
 
 import (
+	"context"
+	"io"
+	"log/slog"
 	"time"
 )
 
-// HttpMethod 表示 HTTP 请求方法
+// HttpMethod 表示 HTTP 请求方法，其他方法可以直接转换，例如 HttpMethod("PROPFIND")
 type HttpMethod string
 
 const (
@@ -101,13 +104,35 @@ type RequestParams struct {
 	Auth       *BasicAuth
 	AuthBearer string
 	Params     map[string]string
+	// ParamPairs 为有序的查询参数，允许重复的键，追加在 Params 之后
+	ParamPairs [][2]string
 	Headers    map[string]string
 	Cookies    map[string]string
-	Timeout    time.Duration
-	Content    []byte
-	Data       map[string]interface{}
-	JSON       interface{}
-	Files      map[string]string
+	// Timeout 覆盖客户端的超时时间，小于 0 表示不限制
+	Timeout time.Duration
+	// Context 为请求的父上下文，取消时中止请求和响应体的读取
+	Context context.Context
+	Content []byte
+	// Body 为流式请求体，如果实现了 io.Closer，发送后会被关闭
+	Body io.Reader
+	// ContentLength 为 Body 的长度，0 表示自动识别（无法识别时使用分块编码），-1 表示强制分块编码
+	ContentLength int64
+	// Data 为表单字段，值为 []string 或 []interface{} 时发送多个同名字段；存在 Files 时以 multipart 编码
+	Data map[string]interface{}
+	// DataPairs 为有序的表单字段，允许重复的键，追加在 Data 之后
+	DataPairs [][2]string
+	JSON      interface{}
+	Files     map[string]string
+	// Multipart 为流式 multipart 请求体，Data 和 DataPairs 中的字段会作为普通字段一并发送
+	Multipart *Multipart
+	// OnUploadProgress 在发送请求体时报告进度，最多每 100ms 调用一次，结束时必定调用
+	OnUploadProgress ProgressFunc
+	// OnDownloadProgress 在读取响应体时报告进度，统计的是解压前的字节数
+	OnDownloadProgress ProgressFunc
+	// Stream 为 true 时不预先读取响应体，调用方需通过 Response.Stream 读取并负责关闭
+	Stream bool
+	// MaxBodySize 覆盖客户端的响应体大小限制，小于 0 表示不限制
+	MaxBodySize int64
 }
 
 // ClientRequestParams 扩展 RequestParams 添加客户端特定选项
@@ -117,6 +142,7 @@ type ClientRequestParams struct {
 	ImpersonateOS ImpersonateOS
 	Verify        bool
 	CACertFile    string
+	MaxBodySize   int64
 }
 
 // BasicAuth 表示 HTTP 基本认证凭据
@@ -166,6 +192,7 @@ func Request(method HttpMethod, url string, params ...ClientRequestParams) (*Res
 		WithImpersonateOS(reqParams.ImpersonateOS),
 		WithVerify(reqParams.Verify),
 		WithCACertFile(reqParams.CACertFile),
+		WithMaxBodySize(reqParams.MaxBodySize),
 	)
 
 	return client.Request(method, url, reqParams.RequestParams)
@@ -201,3 +228,69 @@ func WithCACertFile(caCertFile string) Option {
 		c.caCertFile = caCertFile
 	}
 }
+
+// WithProxy 设置代理 URL，未设置时使用环境变量 PRIMP_PROXY
+func WithProxy(proxy string) Option {
+	return func(c *Client) {
+		c.proxy = proxy
+	}
+}
+
+// WithParams 设置每个请求都会附带的查询参数
+func WithParams(params map[string]string) Option {
+	return func(c *Client) {
+		c.params = params
+	}
+}
+
+// WithParamsPolicy 设置客户端参数与请求参数同名时的处理方式
+func WithParamsPolicy(policy ParamsPolicy) Option {
+	return func(c *Client) {
+		c.paramsPolicy = policy
+	}
+}
+
+// WithMiddleware 添加中间件，与 Client.Use 相同
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(c *Client) {
+		c.Use(middlewares...)
+	}
+}
+
+// WithLogger 设置记录请求的 slog.Logger
+// Info 级别记录方法、URL、状态码、耗时、协议和代理，Debug 级别额外记录隐藏敏感值后的头部和请求体、响应体片段，
+// 表单和 JSON 请求体中密码、令牌类字段的值会被隐藏，压缩的响应体片段解码后记录
+// 日志针对逻辑请求：头部不含 Cookie Jar 添加的 Cookie，重定向只记录最终 URL 和次数
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithTelemetry 启用 OpenTelemetry，每个请求生成一个 client span 并记录耗时、进行中请求数和错误数指标
+func WithTelemetry(opts ...TelemetryOption) Option {
+	return func(c *Client) {
+		c.telemetry = newTelemetry(opts)
+	}
+}
+
+// WithHARRecorder 将经过客户端的所有请求和响应记录到 recorder
+func WithHARRecorder(recorder *HARRecorder) Option {
+	return func(c *Client) {
+		c.harRecorder = recorder
+	}
+}
+
+// WithReplay 使用 replayer 中记录的响应代替网络请求
+func WithReplay(replayer *Replayer) Option {
+	return func(c *Client) {
+		c.replayer = replayer
+	}
+}
+
+// WithMaxBodySize 设置响应体允许的最大字节数，超过时返回 ErrBodyTooLarge（0 表示不限制）
+func WithMaxBodySize(maxBodySize int64) Option {
+	return func(c *Client) {
+		c.maxBodySize = maxBodySize
+	}
+}
@@ -0,0 +1,57 @@
+package primp
+
+import (
+	"io"
+	"time"
+)
+
+// ProgressFunc 报告已传输的字节数和总字节数，总字节数未知时为 -1
+type ProgressFunc func(transferred, total int64)
+
+// progressInterval 是两次进度回调之间的最小间隔
+const progressInterval = 100 * time.Millisecond
+
+// progressReader 在读取时统计字节数并按间隔回调，读取结束或出错时保证回调一次最终进度
+type progressReader struct {
+	reader      io.Reader
+	total       int64
+	transferred int64
+	fn          ProgressFunc
+	last        time.Time
+	done        bool
+}
+
+// newProgressReader 包装 reader，total 小于等于 0 时视为未知
+func newProgressReader(reader io.Reader, total int64, fn ProgressFunc) *progressReader {
+	if total <= 0 {
+		total = -1
+	}
+	return &progressReader{reader: reader, total: total, fn: fn}
+}
+
+// Read 实现 io.Reader 接口
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.reader.Read(b)
+	p.transferred += int64(n)
+	if p.done {
+		return n, err
+	}
+	// 读取出错时同样报告最终进度，调用方能看到中断前传输了多少
+	finished := err != nil || (p.total > 0 && p.transferred >= p.total)
+	if finished {
+		p.done = true
+	}
+	if now := time.Now(); finished || (n > 0 && now.Sub(p.last) >= progressInterval) {
+		p.last = now
+		p.fn(p.transferred, p.total)
+	}
+	return n, err
+}
+
+// Close 关闭底层的 reader
+func (p *progressReader) Close() error {
+	if closer, ok := p.reader.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package primp
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// progressCall 记录一次进度回调
+type progressCall struct {
+	transferred, total int64
+}
+
+// progressRecorder 返回记录回调的 ProgressFunc，上传进度可能在其他协程中回调
+func progressRecorder() (ProgressFunc, func() []progressCall) {
+	var mu sync.Mutex
+	var calls []progressCall
+	fn := func(transferred, total int64) {
+		mu.Lock()
+		calls = append(calls, progressCall{transferred, total})
+		mu.Unlock()
+	}
+	return fn, func() []progressCall {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]progressCall(nil), calls...)
+	}
+}
+
+func TestProgressReaderRateLimit(t *testing.T) {
+	fn, calls := progressRecorder()
+	reader := newProgressReader(iotest.OneByteReader(strings.NewReader(strings.Repeat("x", 100))), 100, fn)
+
+	buf := make([]byte, 1)
+	for i := 0; i < 50; i++ {
+		reader.Read(buf)
+	}
+	// 第一次读取立即回调，之后 100ms 内的读取不回调
+	if got := calls(); len(got) != 1 || got[0] != (progressCall{1, 100}) {
+		t.Fatalf("calls = %v, want only the first read reported", got)
+	}
+
+	reader.last = time.Now().Add(-progressInterval)
+	reader.Read(buf)
+	if got := calls(); len(got) != 2 || got[1] != (progressCall{51, 100}) {
+		t.Fatalf("calls = %v, want a callback once the interval has passed", got)
+	}
+
+	if _, err := io.ReadAll(reader); err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	got := calls()
+	if last := got[len(got)-1]; last != (progressCall{100, 100}) {
+		t.Errorf("final call = %v, want {100 100}", last)
+	}
+	// 到达总长度后 EOF 不再重复回调
+	if len(got) != 3 {
+		t.Errorf("calls = %v, want 3", got)
+	}
+}
+
+func TestProgressReaderFinalCallback(t *testing.T) {
+	errBroken := errors.New("broken")
+	tests := []struct {
+		name    string
+		reader  io.Reader
+		total   int64
+		want    progressCall
+		wantErr error
+	}{
+		{"unknown total at EOF", strings.NewReader("hello"), 0, progressCall{5, -1}, nil},
+		{"negative total is unknown", strings.NewReader("hello"), -1, progressCall{5, -1}, nil},
+		{"short body at EOF", strings.NewReader("hel"), 5, progressCall{3, 5}, nil},
+		{"read error", io.MultiReader(strings.NewReader("hel"), iotest.ErrReader(errBroken)), 5, progressCall{3, 5}, errBroken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn, calls := progressRecorder()
+			reader := newProgressReader(tt.reader, tt.total, fn)
+			reader.last = time.Now()
+			_, err := io.ReadAll(reader)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReadAll() error = %v, want %v", err, tt.wantErr)
+			}
+			got := calls()
+			if len(got) != 1 || got[0] != tt.want {
+				t.Errorf("calls = %v, want exactly the final %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUploadProgressMultipart(t *testing.T) {
+	received := make(chan int64, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := io.Copy(io.Discard, r.Body)
+		received <- n
+	}))
+	defer server.Close()
+
+	upload, uploadCalls := progressRecorder()
+	download, downloadCalls := progressRecorder()
+	form := NewMultipart().AddField("name", "value").AddReader("file", "a.txt", strings.NewReader(strings.Repeat("data", 1000)))
+	_, err := NewClient().Post(server.URL, RequestParams{Multipart: form, OnUploadProgress: upload, OnDownloadProgress: download})
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+
+	sent := <-received
+	got := uploadCalls()
+	if len(got) == 0 {
+		t.Fatal("upload progress never reported")
+	}
+	if last := got[len(got)-1]; last.transferred != sent || (last.total != -1 && last.total != sent) {
+		t.Errorf("final upload call = %v, want %d bytes sent", last, sent)
+	}
+	if got := downloadCalls(); len(got) != 1 || got[0] != (progressCall{0, -1}) {
+		t.Errorf("download calls = %v, want one final call for the empty body", got)
+	}
+}
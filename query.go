@@ -0,0 +1,140 @@
+package primp
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+)
+
+// Node 表示 HTML 文档中通过 CSS 选择器或 XPath 查询到的节点
+type Node struct {
+	node *html.Node
+}
+
+// Select 使用 CSS 选择器查询响应中的元素
+func (r *Response) Select(selector string) ([]*Node, error) {
+	doc, err := r.document()
+	if err != nil {
+		return nil, err
+	}
+	return selectNodes(doc, selector)
+}
+
+// SelectFirst 返回第一个匹配 CSS 选择器的元素，没有匹配时返回 nil
+func (r *Response) SelectFirst(selector string) (*Node, error) {
+	nodes, err := r.Select(selector)
+	if err != nil || len(nodes) == 0 {
+		return nil, err
+	}
+	return nodes[0], nil
+}
+
+// XPath 使用 XPath 表达式查询响应中的节点
+func (r *Response) XPath(expr string) ([]*Node, error) {
+	doc, err := r.document()
+	if err != nil {
+		return nil, err
+	}
+	return xpathNodes(doc, expr)
+}
+
+// selectNodes 在 root 下执行 CSS 选择器查询
+func selectNodes(root *html.Node, selector string) ([]*Node, error) {
+	sel, err := cascadia.Compile(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSS selector: %w", err)
+	}
+	return wrapNodes(sel.MatchAll(root)), nil
+}
+
+// xpathNodes 在 root 下执行 XPath 查询
+func xpathNodes(root *html.Node, expr string) ([]*Node, error) {
+	nodes, err := htmlquery.QueryAll(root, expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid XPath expression: %w", err)
+	}
+	return wrapNodes(nodes), nil
+}
+
+// wrapNodes 将 html.Node 列表包装为 Node 列表
+func wrapNodes(nodes []*html.Node) []*Node {
+	result := make([]*Node, 0, len(nodes))
+	for _, n := range nodes {
+		result = append(result, &Node{node: n})
+	}
+	return result
+}
+
+// Select 在当前节点下使用 CSS 选择器查询
+func (n *Node) Select(selector string) ([]*Node, error) {
+	return selectNodes(n.node, selector)
+}
+
+// XPath 以当前节点为上下文执行 XPath 查询
+func (n *Node) XPath(expr string) ([]*Node, error) {
+	return xpathNodes(n.node, expr)
+}
+
+// Tag 返回元素的标签名
+func (n *Node) Tag() string {
+	if n.node.Type != html.ElementNode {
+		return ""
+	}
+	return n.node.Data
+}
+
+// Text 返回节点压缩空白后的文本内容
+func (n *Node) Text() string {
+	return innerText(n.node)
+}
+
+// Attr 返回属性值，属性不存在时返回空字符串
+func (n *Node) Attr(name string) string {
+	value, _ := getAttr(n.node, name)
+	return value
+}
+
+// HasAttr 判断是否存在指定属性
+func (n *Node) HasAttr(name string) bool {
+	_, ok := getAttr(n.node, name)
+	return ok
+}
+
+// Attrs 返回节点的全部属性
+func (n *Node) Attrs() map[string]string {
+	attrs := make(map[string]string, len(n.node.Attr))
+	for _, attr := range n.node.Attr {
+		attrs[attr.Key] = attr.Val
+	}
+	return attrs
+}
+
+// HTML 返回包含节点自身的 HTML
+func (n *Node) HTML() string {
+	var buf bytes.Buffer
+	if err := html.Render(&buf, n.node); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// InnerHTML 返回节点子元素的 HTML
+func (n *Node) InnerHTML() string {
+	var sb strings.Builder
+	for child := n.node.FirstChild; child != nil; child = child.NextSibling {
+		var buf bytes.Buffer
+		if err := html.Render(&buf, child); err == nil {
+			sb.Write(buf.Bytes())
+		}
+	}
+	return sb.String()
+}
+
+// HTMLNode 返回底层的 html.Node
+func (n *Node) HTMLNode() *html.Node {
+	return n.node
+}
@@ -0,0 +1,170 @@
+package primp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const queryPage = `<!DOCTYPE html><html><body>
+<ul id="items">
+  <li class="item" data-id="1"><a href="/one">One</a></li>
+  <li class="item featured" data-id="2"><a href="/two">Two</a></li>
+  <li class="item" data-id="3"><a href="/three">  Three
+    items </a></li>
+</ul>
+<p class="note">Hello <b>world</b></p>
+</body></html>`
+
+// queryResponse 返回内容为 queryPage 的响应
+func queryResponse(t *testing.T) *Response {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(queryPage))
+	}))
+	t.Cleanup(server.Close)
+
+	resp, err := NewClient().Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	return resp
+}
+
+// nodeTexts 返回节点列表的文本
+func nodeTexts(nodes []*Node) []string {
+	texts := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		texts = append(texts, n.Text())
+	}
+	return texts
+}
+
+func TestResponseQuery(t *testing.T) {
+	resp := queryResponse(t)
+
+	tests := []struct {
+		name  string
+		query func() ([]*Node, error)
+		want  []string
+	}{
+		{"css class", func() ([]*Node, error) { return resp.Select("li.item") }, []string{"One", "Two", "Three items"}},
+		{"css compound", func() ([]*Node, error) { return resp.Select("li.featured > a") }, []string{"Two"}},
+		{"css attribute", func() ([]*Node, error) { return resp.Select(`li[data-id="3"]`) }, []string{"Three items"}},
+		{"css no match", func() ([]*Node, error) { return resp.Select("table td") }, []string{}},
+		{"xpath elements", func() ([]*Node, error) { return resp.XPath("//li/a") }, []string{"One", "Two", "Three items"}},
+		{"xpath predicate", func() ([]*Node, error) { return resp.XPath(`//li[@data-id="2"]`) }, []string{"Two"}},
+		{"xpath attribute", func() ([]*Node, error) { return resp.XPath("//li/@data-id") }, []string{"1", "2", "3"}},
+		{"xpath no match", func() ([]*Node, error) { return resp.XPath("//table") }, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes, err := tt.query()
+			if err != nil {
+				t.Fatalf("query error = %v", err)
+			}
+			got := nodeTexts(nodes)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+				t.Errorf("texts = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResponseQueryErrors(t *testing.T) {
+	resp := queryResponse(t)
+
+	if nodes, err := resp.Select("li[data-id"); err == nil || !strings.Contains(err.Error(), "invalid CSS selector") {
+		t.Errorf("Select() = %v, %v; want invalid CSS selector error", nodes, err)
+	}
+	if node, err := resp.SelectFirst("::"); err == nil || node != nil {
+		t.Errorf("SelectFirst() = %v, %v; want nil and an error", node, err)
+	}
+	if nodes, err := resp.XPath("//li[@data-id="); err == nil || !strings.Contains(err.Error(), "invalid XPath expression") {
+		t.Errorf("XPath() = %v, %v; want invalid XPath expression error", nodes, err)
+	}
+
+	ul, err := resp.SelectFirst("#items")
+	if err != nil || ul == nil {
+		t.Fatalf("SelectFirst(#items) = %v, %v", ul, err)
+	}
+	if _, err := ul.Select("a["); err == nil {
+		t.Error("Node.Select() error = nil, want invalid CSS selector error")
+	}
+	if _, err := ul.XPath("li["); err == nil {
+		t.Error("Node.XPath() error = nil, want invalid XPath expression error")
+	}
+}
+
+func TestResponseSelectFirst(t *testing.T) {
+	resp := queryResponse(t)
+
+	node, err := resp.SelectFirst("li.item")
+	if err != nil || node == nil {
+		t.Fatalf("SelectFirst() = %v, %v", node, err)
+	}
+	if node.Attr("data-id") != "1" {
+		t.Errorf("SelectFirst() data-id = %q, want %q", node.Attr("data-id"), "1")
+	}
+
+	node, err = resp.SelectFirst("table")
+	if err != nil || node != nil {
+		t.Errorf("SelectFirst(no match) = %v, %v; want nil, nil", node, err)
+	}
+}
+
+func TestNodeAccessors(t *testing.T) {
+	resp := queryResponse(t)
+
+	ul, err := resp.SelectFirst("#items")
+	if err != nil || ul == nil {
+		t.Fatalf("SelectFirst(#items) = %v, %v", ul, err)
+	}
+
+	// 子查询以当前节点为范围
+	links, err := ul.Select("a")
+	if err != nil || len(links) != 3 {
+		t.Fatalf("Node.Select(a) = %d nodes, %v; want 3", len(links), err)
+	}
+	if links[0].Attr("href") != "/one" || links[0].Tag() != "a" {
+		t.Errorf("first link = <%s href=%q>, want <a href=%q>", links[0].Tag(), links[0].Attr("href"), "/one")
+	}
+	if outside, _ := ul.Select("p"); len(outside) != 0 {
+		t.Errorf("Node.Select(p) = %d nodes, want 0 outside the current node", len(outside))
+	}
+	featured, err := ul.XPath(`./li[contains(@class, "featured")]`)
+	if err != nil || len(featured) != 1 || featured[0].Text() != "Two" {
+		t.Errorf("Node.XPath() = %v, %v; want the featured item", nodeTexts(featured), err)
+	}
+
+	item := featured[0]
+	if !item.HasAttr("data-id") || item.HasAttr("title") {
+		t.Errorf("HasAttr(data-id, title) = %v, %v; want true, false", item.HasAttr("data-id"), item.HasAttr("title"))
+	}
+	if item.Attr("title") != "" {
+		t.Errorf("Attr(title) = %q, want empty", item.Attr("title"))
+	}
+	if attrs := item.Attrs(); attrs["class"] != "item featured" || attrs["data-id"] != "2" || len(attrs) != 2 {
+		t.Errorf("Attrs() = %v", attrs)
+	}
+
+	note, err := resp.SelectFirst("p.note")
+	if err != nil || note == nil {
+		t.Fatalf("SelectFirst(p.note) = %v, %v", note, err)
+	}
+	if got, want := note.HTML(), `<p class="note">Hello <b>world</b></p>`; got != want {
+		t.Errorf("HTML() = %q, want %q", got, want)
+	}
+	if got, want := note.InnerHTML(), `Hello <b>world</b>`; got != want {
+		t.Errorf("InnerHTML() = %q, want %q", got, want)
+	}
+	if note.Text() != "Hello world" {
+		t.Errorf("Text() = %q, want %q", note.Text(), "Hello world")
+	}
+	if note.HTMLNode().Data != "p" {
+		t.Errorf("HTMLNode().Data = %q, want %q", note.HTMLNode().Data, "p")
+	}
+}
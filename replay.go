@@ -0,0 +1,377 @@
+package primp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ErrNoReplayMatch 表示回放数据中没有与请求匹配的响应
+var ErrNoReplayMatch = errors.New("no recorded response matches request")
+
+// ReplayOption 是配置 Replayer 的函数类型
+type ReplayOption func(*Replayer)
+
+// ReplayMatchBody 要求请求体与记录的请求体完全一致，默认只匹配方法和 URL
+func ReplayMatchBody() ReplayOption {
+	return func(r *Replayer) {
+		r.matchBody = true
+	}
+}
+
+// ReplayMatchHeaders 要求指定的请求头与记录的值一致
+func ReplayMatchHeaders(names ...string) ReplayOption {
+	return func(r *Replayer) {
+		for _, name := range names {
+			r.matchHeaders = append(r.matchHeaders, http.CanonicalHeaderKey(name))
+		}
+	}
+}
+
+// ReplayIgnoreParams 匹配 URL 时忽略指定的查询参数（如时间戳），不指定参数时忽略整个查询字符串
+func ReplayIgnoreParams(names ...string) ReplayOption {
+	return func(r *Replayer) {
+		if len(names) == 0 {
+			r.ignoreQuery = true
+			return
+		}
+		if r.ignoreParams == nil {
+			r.ignoreParams = make(map[string]bool)
+		}
+		for _, name := range names {
+			r.ignoreParams[name] = true
+		}
+	}
+}
+
+// ReplayMatcher 添加自定义匹配规则，所有规则都返回 true 时才视为匹配
+func ReplayMatcher(fn func(req *http.Request, body []byte, recorded *HAREntry) bool) ReplayOption {
+	return func(r *Replayer) {
+		r.matchers = append(r.matchers, fn)
+	}
+}
+
+// ReplayPassthrough 没有匹配的记录时发送真实请求，默认返回 ErrNoReplayMatch
+func ReplayPassthrough() ReplayOption {
+	return func(r *Replayer) {
+		r.passthrough = true
+	}
+}
+
+// Replayer 使用记录的响应代替网络请求，用于离线测试
+// 多个记录匹配同一请求时按记录顺序依次返回，用完后重复返回最后一个
+type Replayer struct {
+	entries []*replayEntry
+
+	matchBody    bool
+	matchHeaders []string
+	ignoreQuery  bool
+	ignoreParams map[string]bool
+	matchers     []func(req *http.Request, body []byte, recorded *HAREntry) bool
+	passthrough  bool
+
+	mu sync.Mutex
+}
+
+// replayEntry 是一条记录及其使用状态
+type replayEntry struct {
+	entry HAREntry
+	body  []byte
+	used  bool
+}
+
+// NewReplayer 使用 HAR 中的条目创建 Replayer，跳过失败请求的条目，状态码无效或 base64 响应体无法解码时返回错误
+func NewReplayer(har *HAR, opts ...ReplayOption) (*Replayer, error) {
+	r := &Replayer{}
+	for _, opt := range opts {
+		opt(r)
+	}
+	for i, entry := range har.Log.Entries {
+		// 状态码为 0 的条目表示请求失败（浏览器和 HARRecorder 都这样记录），没有可回放的响应
+		status := entry.Response.Status
+		if status == 0 {
+			continue
+		}
+		if status < 100 || status > 999 {
+			return nil, fmt.Errorf("invalid HAR entry %d (%s %s): status code %d", i, entry.Request.Method, entry.Request.URL, status)
+		}
+		if entry.Response.Content.Encoding == "base64" {
+			if _, err := base64.StdEncoding.DecodeString(entry.Response.Content.Text); err != nil {
+				return nil, fmt.Errorf("invalid HAR entry %d (%s %s): failed to decode response body: %w", i, entry.Request.Method, entry.Request.URL, err)
+			}
+		}
+		var body []byte
+		if entry.Request.PostData != nil {
+			body = []byte(entry.Request.PostData.Text)
+		}
+		r.entries = append(r.entries, &replayEntry{entry: entry, body: body})
+	}
+	return r, nil
+}
+
+// LoadHAR 读取 HAR 文件
+func LoadHAR(path string) (*HAR, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HAR file: %w", err)
+	}
+	var har HAR
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("failed to parse HAR file: %w", err)
+	}
+	return &har, nil
+}
+
+// NewReplayerFromHAR 使用 HAR 文件创建 Replayer
+func NewReplayerFromHAR(path string, opts ...ReplayOption) (*Replayer, error) {
+	har, err := LoadHAR(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplayer(har, opts...)
+}
+
+// replayFixture 是固定响应文件的格式
+type replayFixture struct {
+	Request struct {
+		Method  string            `json:"method"`
+		URL     string            `json:"url"`
+		Headers map[string]string `json:"headers"`
+		Body    string            `json:"body"`
+	} `json:"request"`
+	Response struct {
+		Status   int               `json:"status"`
+		Headers  map[string]string `json:"headers"`
+		Body     string            `json:"body"`
+		BodyFile string            `json:"bodyFile"`
+	} `json:"response"`
+}
+
+// NewReplayerFromDir 使用目录中的 *.json 固定响应文件创建 Replayer，文件按名称顺序加载，格式为：
+//
+//	{"request": {"method": "GET", "url": "https://example.com/", "headers": {}, "body": ""},
+//	 "response": {"status": 200, "headers": {"Content-Type": "text/html"}, "body": "...", "bodyFile": "page.html"}}
+//
+// bodyFile 为相对于该文件的路径，设置时代替 body
+func NewReplayerFromDir(dir string, opts ...ReplayOption) (*Replayer, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fixtures: %w", err)
+	}
+	sort.Strings(paths)
+
+	har := &HAR{}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture %s: %w", path, err)
+		}
+		var fixture replayFixture
+		if err := json.Unmarshal(data, &fixture); err != nil {
+			return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+		}
+
+		body := fixture.Response.Body
+		if fixture.Response.BodyFile != "" {
+			content, err := os.ReadFile(filepath.Join(filepath.Dir(path), fixture.Response.BodyFile))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read fixture %s: %w", path, err)
+			}
+			body = string(content)
+		}
+		status := fixture.Response.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		method := fixture.Request.Method
+		if method == "" {
+			method = string(GET)
+		}
+
+		entry := HAREntry{
+			Request: HARRequest{Method: method, URL: fixture.Request.URL, Headers: mapHeaders(fixture.Request.Headers)},
+			Response: HARResponse{
+				Status:     status,
+				StatusText: http.StatusText(status),
+				Headers:    mapHeaders(fixture.Response.Headers),
+				Content:    HARContent{Size: int64(len(body)), MimeType: fixture.Response.Headers["Content-Type"]},
+			},
+		}
+		if fixture.Request.Body != "" {
+			entry.Request.PostData = &HARPostData{Text: fixture.Request.Body}
+		}
+		if utf8.Valid([]byte(body)) {
+			entry.Response.Content.Text = body
+		} else {
+			entry.Response.Content.Text = base64.StdEncoding.EncodeToString([]byte(body))
+			entry.Response.Content.Encoding = "base64"
+		}
+		har.Log.Entries = append(har.Log.Entries, entry)
+	}
+	return NewReplayer(har, opts...)
+}
+
+// transport 返回使用记录响应的 RoundTripper，base 用于未匹配时的真实请求
+func (r *Replayer) transport(base http.RoundTripper) http.RoundTripper {
+	return &replayTransport{replayer: r, base: base}
+}
+
+// replayTransport 在传输层回放响应，重定向会按记录逐跳回放
+type replayTransport struct {
+	replayer *Replayer
+	base     http.RoundTripper
+}
+
+// RoundTrip 实现 http.RoundTripper 接口
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	if entry := t.replayer.match(req, body); entry != nil {
+		return replayResponse(req, entry)
+	}
+	if t.replayer.passthrough {
+		return t.base.RoundTrip(req)
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrNoReplayMatch, req.Method, req.URL.Redacted())
+}
+
+// match 返回与请求匹配的记录，优先返回未使用过的记录
+func (r *Replayer) match(req *http.Request, body []byte) *HAREntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var last *replayEntry
+	for _, candidate := range r.entries {
+		if !r.matches(req, body, candidate) {
+			continue
+		}
+		if !candidate.used {
+			candidate.used = true
+			return &candidate.entry
+		}
+		last = candidate
+	}
+	if last != nil {
+		return &last.entry
+	}
+	return nil
+}
+
+// matches 判断记录是否与请求匹配
+func (r *Replayer) matches(req *http.Request, body []byte, candidate *replayEntry) bool {
+	recorded := &candidate.entry
+	if !strings.EqualFold(recorded.Request.Method, req.Method) {
+		return false
+	}
+	recordedURL, err := url.Parse(recorded.Request.URL)
+	if err != nil || r.normalizeURL(recordedURL) != r.normalizeURL(req.URL) {
+		return false
+	}
+	if r.matchBody && !bytes.Equal(candidate.body, body) {
+		return false
+	}
+	for _, name := range r.matchHeaders {
+		if harHeader(recorded.Request.Headers, name) != req.Header.Get(name) {
+			return false
+		}
+	}
+	for _, matcher := range r.matchers {
+		if !matcher(req, body, recorded) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeURL 返回用于比较的 URL，查询参数按名称排序并去掉忽略的参数
+func (r *Replayer) normalizeURL(u *url.URL) string {
+	normalized := strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Host) + u.EscapedPath()
+	if r.ignoreQuery {
+		return normalized
+	}
+
+	var pairs []string
+	for _, pair := range queryPairs(u.RawQuery) {
+		if !r.ignoreParams[pair.Name] {
+			pairs = append(pairs, url.QueryEscape(pair.Name)+"="+url.QueryEscape(pair.Value))
+		}
+	}
+	sort.Strings(pairs)
+	if len(pairs) > 0 {
+		normalized += "?" + strings.Join(pairs, "&")
+	}
+	return normalized
+}
+
+// replayResponse 使用记录构造响应，记录中的内容已解压，因此去掉 Content-Encoding 等头部
+// 内容被截断的记录无法还原响应，返回错误
+func replayResponse(req *http.Request, entry *HAREntry) (*http.Response, error) {
+	if entry.Response.Content.Truncated {
+		return nil, fmt.Errorf("recorded response for %s %s is truncated: %s", req.Method, req.URL.Redacted(), entry.Response.Content.Comment)
+	}
+	body := []byte(entry.Response.Content.Text)
+	if entry.Response.Content.Encoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(entry.Response.Content.Text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode recorded response body: %w", err)
+		}
+		body = decoded
+	}
+
+	header := http.Header{}
+	for _, h := range entry.Response.Headers {
+		switch http.CanonicalHeaderKey(h.Name) {
+		case "Content-Encoding", "Content-Length", "Transfer-Encoding":
+			continue
+		}
+		header.Add(h.Name, h.Value)
+	}
+	// 解压失败时记录的仍是压缩数据，保留编码让客户端按原样解码
+	if encoding := entry.Response.Content.ContentEncoding; encoding != "" {
+		header.Set("Content-Encoding", encoding)
+	}
+	resp := NewSyntheticResponse(req, entry.Response.Status, header, body)
+	if entry.Response.StatusText != "" {
+		resp.Status = fmt.Sprintf("%d %s", entry.Response.Status, entry.Response.StatusText)
+	}
+	return resp, nil
+}
+
+// harHeader 返回 HAR 头部列表中指定名称的值
+func harHeader(headers []HARNameValue, name string) string {
+	for _, h := range headers {
+		if strings.EqualFold(h.Name, name) {
+			return h.Value
+		}
+	}
+	return ""
+}
+
+// mapHeaders 将头部 map 转换为按名称排序的 HAR 头部
+func mapHeaders(headers map[string]string) []HARNameValue {
+	result := []HARNameValue{}
+	for _, pair := range sortedPairs(headers) {
+		result = append(result, HARNameValue{Name: pair[0], Value: pair[1]})
+	}
+	return result
+}
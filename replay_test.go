@@ -0,0 +1,254 @@
+package primp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// replayEntryFor 创建返回 text 的 HAR 条目
+func replayEntryFor(method, url, requestBody, text string, headers ...HARNameValue) HAREntry {
+	entry := HAREntry{
+		Request: HARRequest{Method: method, URL: url, Headers: headers},
+		Response: HARResponse{
+			Status:  http.StatusOK,
+			Headers: []HARNameValue{{Name: "Content-Type", Value: "text/plain"}},
+			Content: HARContent{Size: int64(len(text)), Text: text},
+		},
+	}
+	if requestBody != "" {
+		entry.Request.PostData = &HARPostData{Text: requestBody}
+	}
+	return entry
+}
+
+// replayRequest 描述一次回放请求及期望的结果
+type replayRequest struct {
+	method  HttpMethod
+	url     string
+	params  RequestParams
+	want    string
+	wantErr error
+}
+
+func TestReplayer(t *testing.T) {
+	tests := []struct {
+		name     string
+		entries  []HAREntry
+		opts     []ReplayOption
+		requests []replayRequest
+	}{
+		{
+			name:    "method and URL",
+			entries: []HAREntry{replayEntryFor("GET", "https://example.com/a?x=1&y=2", "", "a")},
+			requests: []replayRequest{
+				{method: GET, url: "https://example.com/a?y=2&x=1", want: "a"},
+				{method: POST, url: "https://example.com/a?x=1&y=2", wantErr: ErrNoReplayMatch},
+				{method: GET, url: "https://example.com/b", wantErr: ErrNoReplayMatch},
+			},
+		},
+		{
+			name: "match body",
+			entries: []HAREntry{
+				replayEntryFor("POST", "https://example.com/api", "one", "first"),
+				replayEntryFor("POST", "https://example.com/api", "two", "second"),
+			},
+			opts: []ReplayOption{ReplayMatchBody()},
+			requests: []replayRequest{
+				{method: POST, url: "https://example.com/api", params: RequestParams{Content: []byte("two")}, want: "second"},
+				{method: POST, url: "https://example.com/api", params: RequestParams{Content: []byte("one")}, want: "first"},
+				{method: POST, url: "https://example.com/api", params: RequestParams{Content: []byte("three")}, wantErr: ErrNoReplayMatch},
+			},
+		},
+		{
+			name: "match headers",
+			entries: []HAREntry{
+				replayEntryFor("GET", "https://example.com/", "", "v1", HARNameValue{Name: "x-api-version", Value: "1"}),
+				replayEntryFor("GET", "https://example.com/", "", "v2", HARNameValue{Name: "X-Api-Version", Value: "2"}),
+			},
+			opts: []ReplayOption{ReplayMatchHeaders("x-api-version")},
+			requests: []replayRequest{
+				{method: GET, url: "https://example.com/", params: RequestParams{Headers: map[string]string{"X-Api-Version": "2"}}, want: "v2"},
+				{method: GET, url: "https://example.com/", params: RequestParams{Headers: map[string]string{"X-Api-Version": "3"}}, wantErr: ErrNoReplayMatch},
+			},
+		},
+		{
+			name:    "ignore params",
+			entries: []HAREntry{replayEntryFor("GET", "https://example.com/?q=go&ts=1", "", "results")},
+			opts:    []ReplayOption{ReplayIgnoreParams("ts")},
+			requests: []replayRequest{
+				{method: GET, url: "https://example.com/?ts=99&q=go", want: "results"},
+				{method: GET, url: "https://example.com/?q=rust&ts=1", wantErr: ErrNoReplayMatch},
+			},
+		},
+		{
+			name:    "ignore query",
+			entries: []HAREntry{replayEntryFor("GET", "https://example.com/?q=go", "", "results")},
+			opts:    []ReplayOption{ReplayIgnoreParams()},
+			requests: []replayRequest{
+				{method: GET, url: "https://example.com/?anything=else", want: "results"},
+			},
+		},
+		{
+			name: "repeated entries in order",
+			entries: []HAREntry{
+				replayEntryFor("GET", "https://example.com/poll", "", "pending"),
+				replayEntryFor("GET", "https://example.com/poll", "", "done"),
+			},
+			requests: []replayRequest{
+				{method: GET, url: "https://example.com/poll", want: "pending"},
+				{method: GET, url: "https://example.com/poll", want: "done"},
+				{method: GET, url: "https://example.com/poll", want: "done"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replayer, err := NewReplayer(&HAR{Log: HARLog{Entries: tt.entries}}, tt.opts...)
+			if err != nil {
+				t.Fatalf("NewReplayer() error = %v", err)
+			}
+			client := NewClient(WithReplay(replayer))
+			for i, r := range tt.requests {
+				resp, err := client.Request(r.method, r.url, r.params)
+				if r.wantErr != nil {
+					if !errors.Is(err, r.wantErr) {
+						t.Errorf("request %d: error = %v, want %v", i, err, r.wantErr)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("request %d: error = %v", i, err)
+				}
+				if text, _ := resp.Text(); text != r.want {
+					t.Errorf("request %d: body = %q, want %q", i, text, r.want)
+				}
+			}
+		})
+	}
+}
+
+func TestReplayPassthrough(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("live"))
+	}))
+	defer server.Close()
+
+	har := &HAR{Log: HARLog{Entries: []HAREntry{replayEntryFor("GET", server.URL+"/recorded", "", "recorded")}}}
+	replayer, err := NewReplayer(har, ReplayPassthrough())
+	if err != nil {
+		t.Fatalf("NewReplayer() error = %v", err)
+	}
+	client := NewClient(WithReplay(replayer))
+	for path, want := range map[string]string{"/recorded": "recorded", "/other": "live"} {
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("Get(%s) error = %v", path, err)
+		}
+		if text, _ := resp.Text(); text != want {
+			t.Errorf("Get(%s) = %q, want %q", path, text, want)
+		}
+	}
+}
+
+func TestNewReplayerFromHAR(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/start" {
+			http.Redirect(w, r, "/end", http.StatusFound)
+			return
+		}
+		w.Write([]byte("end"))
+	}))
+	recorder := NewHARRecorder()
+	if _, err := NewClient(WithHARRecorder(recorder)).Get(server.URL + "/start"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	server.Close()
+	path := filepath.Join(t.TempDir(), "session.har")
+	if err := recorder.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	replayer, err := NewReplayerFromHAR(path)
+	if err != nil {
+		t.Fatalf("NewReplayerFromHAR() error = %v", err)
+	}
+	resp, err := NewClient(WithReplay(replayer)).Get(server.URL + "/start")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if text, _ := resp.Text(); text != "end" || resp.URL != server.URL+"/end" {
+		t.Errorf("replayed %q at %s, want %q after the recorded redirect", text, resp.URL, "end")
+	}
+}
+
+func TestNewReplayerFromDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"01_page.json": `{"request": {"url": "https://example.com/"},
+			"response": {"headers": {"Content-Type": "text/html"}, "bodyFile": "page.html"}}`,
+		"02_api.json": `{"request": {"method": "POST", "url": "https://example.com/api", "body": "{}"},
+			"response": {"status": 201, "body": "created"}}`,
+		"page.html": "<p>home</p>",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	replayer, err := NewReplayerFromDir(dir, ReplayMatchBody())
+	if err != nil {
+		t.Fatalf("NewReplayerFromDir() error = %v", err)
+	}
+	client := NewClient(WithReplay(replayer))
+
+	resp, err := client.Get("https://example.com/")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if text, _ := resp.Text(); resp.StatusCode != http.StatusOK || text != "<p>home</p>" {
+		t.Errorf("Get() = %d %q, want 200 with the body file", resp.StatusCode, text)
+	}
+	if headers, _ := resp.Headers(); headers["Content-Type"] != "text/html" {
+		t.Errorf("Content-Type = %q, want text/html", headers["Content-Type"])
+	}
+
+	resp, err = client.Post("https://example.com/api", RequestParams{Content: []byte("{}")})
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	if text, _ := resp.Text(); resp.StatusCode != http.StatusCreated || text != "created" {
+		t.Errorf("Post() = %d %q, want 201 created", resp.StatusCode, text)
+	}
+}
+
+func TestNewReplayerValidatesEntries(t *testing.T) {
+	failed := replayEntryFor("GET", "https://example.com/failed", "", "")
+	failed.Response.Status = 0
+	badStatus := replayEntryFor("GET", "https://example.com/", "", "")
+	badStatus.Response.Status = 42
+	badBody := replayEntryFor("GET", "https://example.com/", "", "not base64!")
+	badBody.Response.Content.Encoding = "base64"
+
+	tests := []struct {
+		name    string
+		entry   HAREntry
+		wantErr bool
+	}{
+		{name: "failed request skipped", entry: failed},
+		{name: "invalid status", entry: badStatus, wantErr: true},
+		{name: "invalid base64 body", entry: badBody, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewReplayer(&HAR{Log: HARLog{Entries: []HAREntry{tt.entry}}})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewReplayer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,9 +2,12 @@ package primp
 
 import (
 	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"runtime"
 	"strings"
 
 	"golang.org/x/net/html"
@@ -15,24 +18,83 @@ import (
 	"golang.org/x/text/transform"
 )
 
+// ErrBodyTooLarge 表示响应体超过了允许的最大大小
+var ErrBodyTooLarge = errors.New("response body exceeds max body size")
+
+// ErrBodyConsumed 表示响应体已经通过 Stream 交给调用方读取
+var ErrBodyConsumed = errors.New("response body already consumed by Stream")
+
 // Response 表示 HTTP 响应
 type Response struct {
-	httpResp   *http.Response
-	content    []byte
-	encoding   string
-	headers    map[string]string
-	cookies    map[string]string
-	URL        string
-	StatusCode int
+	httpResp    *http.Response
+	client      *Client
+	cancel      context.CancelFunc
+	raw         []byte
+	content     []byte
+	doc         *html.Node
+	jsonValue   *interface{}
+	maxBodySize int64
+	streamed    bool
+	closed      bool
+	encoding    string
+	headers     map[string]string
+	cookies     map[string]string
+	URL         string
+	StatusCode  int
 }
 
 // newResponse 从 http.Response 创建新的 Response
-func newResponse(resp *http.Response, url string) (*Response, error) {
-	return &Response{
-		httpResp:   resp,
-		URL:        url,
-		StatusCode: resp.StatusCode,
-	}, nil
+// 非流模式下会立即读取原始响应体并释放连接，解压推迟到首次访问内容时进行
+func newResponse(resp *http.Response, url string, cancel context.CancelFunc, maxBodySize int64, stream bool) (*Response, error) {
+	r := &Response{
+		httpResp:    resp,
+		cancel:      cancel,
+		maxBodySize: maxBodySize,
+		URL:         url,
+		StatusCode:  resp.StatusCode,
+	}
+
+	if stream {
+		// 调用方忘记关闭时，在回收前关闭连接
+		runtime.SetFinalizer(r, (*Response).Close)
+		return r, nil
+	}
+
+	defer r.Close()
+	if maxBodySize > 0 && resp.ContentLength > maxBodySize {
+		return nil, ErrBodyTooLarge
+	}
+	raw, err := readLimited(resp.Body, maxBodySize)
+	if err != nil {
+		return nil, r.bodyReadError(err)
+	}
+	r.raw = raw
+	return r, nil
+}
+
+// readLimited 读取全部数据，超过 limit 字节时返回 ErrBodyTooLarge（limit <= 0 表示不限制）
+func readLimited(reader io.Reader, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		return io.ReadAll(reader)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, ErrBodyTooLarge
+	}
+	return data, nil
+}
+
+// bodyReader 返回按 Content-Encoding 解码的响应体读取器
+func (r *Response) bodyReader() *decodedBody {
+	var source io.Reader = r.httpResp.Body
+	if r.raw != nil {
+		source = bytes.NewReader(r.raw)
+	}
+	return newDecodedBody(source, r.httpResp.Header.Get("Content-Encoding"))
 }
 
 // Content 以字节形式返回响应体
@@ -40,10 +102,18 @@ func (r *Response) Content() ([]byte, error) {
 	if r.content != nil {
 		return r.content, nil
 	}
+	if r.streamed {
+		return nil, ErrBodyConsumed
+	}
 
-	defer r.httpResp.Body.Close()
-	content, err := io.ReadAll(r.httpResp.Body)
+	defer r.Close()
+	reader := r.bodyReader()
+	defer reader.Close()
+	content, err := readLimited(reader, r.maxBodySize)
 	if err != nil {
+		if r.raw == nil {
+			return nil, r.bodyReadError(err)
+		}
 		return nil, err
 	}
 
@@ -51,6 +121,40 @@ func (r *Response) Content() ([]byte, error) {
 	return content, nil
 }
 
+// RawContent 返回未经解压的原始响应体
+func (r *Response) RawContent() ([]byte, error) {
+	if r.raw != nil {
+		return r.raw, nil
+	}
+	if r.streamed {
+		return nil, ErrBodyConsumed
+	}
+
+	defer r.Close()
+	raw, err := readLimited(r.httpResp.Body, r.maxBodySize)
+	if err != nil {
+		return nil, r.bodyReadError(err)
+	}
+
+	r.raw = raw
+	return raw, nil
+}
+
+// Close 关闭响应体并释放连接，可以重复调用
+func (r *Response) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	runtime.SetFinalizer(r, nil)
+
+	err := r.httpResp.Body.Close()
+	if r.cancel != nil {
+		r.cancel()
+	}
+	return err
+}
+
 // Text 以字符串形式返回响应体
 func (r *Response) Text() (string, error) {
 	content, err := r.Content()
@@ -65,7 +169,8 @@ func (r *Response) Text() (string, error) {
 		return string(content), nil
 	}
 
-	reader := transform.NewReader(bytes.NewReader(content), dec.NewDecoder())
+	// BOM 优先于声明的编码，并从结果中去除
+	reader := transform.NewReader(bytes.NewReader(content), unicode.BOMOverride(dec.NewDecoder()))
 	result, err := io.ReadAll(reader)
 	if err != nil {
 		return "", err
@@ -85,16 +190,6 @@ func getDecoder(charset string) (encoding.Encoding, error) {
 	return htmlindex.Get(charset)
 }
 
-// JSON 将响应体反序列化到提供的值中
-func (r *Response) JSON(v interface{}) error {
-	content, err := r.Content()
-	if err != nil {
-		return err
-	}
-
-	return json.Unmarshal(content, v)
-}
-
 // Headers 返回响应头
 func (r *Response) Headers() (map[string]string, error) {
 	if r.headers != nil {
@@ -128,140 +223,128 @@ func (r *Response) Cookies() (map[string]string, error) {
 }
 
 // Encoding 返回响应编码
+// 依次根据 BOM、Content-Type 头部、HTML meta 标签、XML 声明和统计检测确定编码
 func (r *Response) Encoding() string {
 	if r.encoding != "" {
 		return r.encoding
 	}
 
-	contentType := r.httpResp.Header.Get("Content-Type")
-	if contentType != "" {
-		parts := strings.Split(contentType, ";")
-		for _, part := range parts {
-			part = strings.TrimSpace(part)
-			if strings.HasPrefix(strings.ToLower(part), "charset=") {
-				r.encoding = strings.TrimPrefix(part, "charset=")
-				r.encoding = strings.Trim(r.encoding, `"'`)
-				return r.encoding
-			}
-		}
+	// 响应体已交给 Stream 时只能依据头部判断
+	var content []byte
+	if !r.streamed || r.content != nil {
+		content, _ = r.Content()
 	}
 
-	// 默认为 UTF-8
-	r.encoding = "utf-8"
+	r.encoding = detectEncoding(content, r.httpResp.Header.Get("Content-Type"))
 	return r.encoding
 }
 
 // SetEncoding 设置响应编码
 func (r *Response) SetEncoding(encoding string) {
 	r.encoding = encoding
+	r.doc = nil
 }
 
-// Stream 返回响应体的读取器
+// Stream 返回解码后响应体的读取器，关闭读取器即关闭响应
+// 如果响应体已在内存中，则返回内容的副本读取器，之后仍可调用 Content 等方法
 func (r *Response) Stream() (io.ReadCloser, error) {
-	return r.httpResp.Body, nil
-}
-
-// TextMarkdown 以 Markdown 文本形式返回响应
-func (r *Response) TextMarkdown() (string, error) {
-	content, err := r.Content()
-	if err != nil {
-		return "", err
+	if r.content != nil {
+		return io.NopCloser(bytes.NewReader(r.content)), nil
+	}
+	if r.raw != nil {
+		return &bodyStream{reader: r.bodyReader(), remaining: r.maxBodySize, resp: r}, nil
+	}
+	if r.streamed {
+		return nil, ErrBodyConsumed
 	}
 
-	// 简单的 HTML 到 Markdown 转换
-	return htmlToMarkdown(content), nil
+	// 只有直接读取网络连接时响应体才会被消耗
+	r.streamed = true
+	return &bodyStream{reader: r.bodyReader(), remaining: r.maxBodySize, resp: r}, nil
 }
 
-// TextPlain 以纯文本形式返回响应
-func (r *Response) TextPlain() (string, error) {
-	content, err := r.Content()
-	if err != nil {
-		return "", err
-	}
-
-	// 简单的 HTML 到纯文本转换
-	return htmlToPlainText(content), nil
+// bodyStream 是 Stream 返回的读取器，负责执行大小限制
+type bodyStream struct {
+	reader    *decodedBody
+	remaining int64
+	resp      *Response
 }
 
-// TextRich 以富文本形式返回响应
-func (r *Response) TextRich() (string, error) {
-	content, err := r.Content()
-	if err != nil {
-		return "", err
+// Read 实现 io.Reader
+func (s *bodyStream) Read(p []byte) (int, error) {
+	if s.resp.maxBodySize <= 0 {
+		return s.reader.Read(p)
+	}
+	if s.remaining < 0 {
+		return 0, ErrBodyTooLarge
+	}
+	if int64(len(p)) > s.remaining+1 {
+		p = p[:s.remaining+1]
 	}
+	n, err := s.reader.Read(p)
+	s.remaining -= int64(n)
+	if s.remaining < 0 {
+		return n + int(s.remaining), ErrBodyTooLarge
+	}
+	return n, err
+}
 
-	// 简单的 HTML 到富文本转换
-	return htmlToRichText(content), nil
+// Close 实现 io.Closer
+func (s *bodyStream) Close() error {
+	s.reader.Close()
+	return s.resp.Close()
 }
 
-// htmlToMarkdown 将 HTML 转换为 Markdown
-func htmlToMarkdown(htmlContent []byte) string {
-	// 这是一个简化的实现
-	// 在实际实现中，应该使用适当的 HTML 到 Markdown 转换器
-	doc, err := html.Parse(bytes.NewReader(htmlContent))
-	if err != nil {
-		return string(htmlContent)
+// document 解析响应内容为 HTML 文档树，结果会被缓存
+func (r *Response) document() (*html.Node, error) {
+	if r.doc != nil {
+		return r.doc, nil
 	}
 
-	var sb strings.Builder
-	var extractText func(*html.Node)
-	extractText = func(n *html.Node) {
-		if n.Type == html.TextNode {
-			sb.WriteString(n.Data)
-		}
-		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			extractText(c)
-		}
+	text, err := r.Text()
+	if err != nil {
+		return nil, err
+	}
+	doc, err := html.Parse(strings.NewReader(text))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
 	}
-	extractText(doc)
 
-	return sb.String()
+	r.doc = doc
+	return doc, nil
 }
 
-// htmlToPlainText 将 HTML 转换为纯文本
-func htmlToPlainText(htmlContent []byte) string {
-	// 这是一个简化的实现
-	// 在实际实现中，应该使用适当的 HTML 到文本转换器
-	doc, err := html.Parse(bytes.NewReader(htmlContent))
+// TextMarkdown 以 Markdown 文本形式返回响应
+func (r *Response) TextMarkdown() (string, error) {
+	doc, err := r.document()
 	if err != nil {
-		return string(htmlContent)
+		return "", err
 	}
 
-	var sb strings.Builder
-	var extractText func(*html.Node)
-	extractText = func(n *html.Node) {
-		if n.Type == html.TextNode {
-			sb.WriteString(n.Data)
-		}
-		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			extractText(c)
-		}
+	return htmlToMarkdown(doc, r.URL), nil
+}
+
+// TextPlain 以纯文本形式返回响应
+func (r *Response) TextPlain() (string, error) {
+	doc, err := r.document()
+	if err != nil {
+		return "", err
 	}
-	extractText(doc)
 
-	return sb.String()
+	return htmlToPlainText(doc), nil
 }
 
-// htmlToRichText 将 HTML 转换为富文本
-func htmlToRichText(htmlContent []byte) string {
-	// 这是一个简化的实现
-	// 在实际实现中，应该使用适当的 HTML 到富文本转换器
-	doc, err := html.Parse(bytes.NewReader(htmlContent))
+// TextRich 以带 ANSI 样式的终端富文本形式返回响应
+// 可选参数 width 指定换行宽度，默认为 80，小于等于 0 时不换行
+func (r *Response) TextRich(width ...int) (string, error) {
+	doc, err := r.document()
 	if err != nil {
-		return string(htmlContent)
+		return "", err
 	}
 
-	var sb strings.Builder
-	var extractText func(*html.Node)
-	extractText = func(n *html.Node) {
-		if n.Type == html.TextNode {
-			sb.WriteString(n.Data)
-		}
-		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			extractText(c)
-		}
+	lineWidth := defaultRichTextWidth
+	if len(width) > 0 {
+		lineWidth = width[0]
 	}
-	extractText(doc)
-
-	return sb.String()
+	return htmlToRichText(doc, r.URL, lineWidth), nil
 }
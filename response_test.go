@@ -0,0 +1,270 @@
+package primp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestStreamBufferedResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	resp, err := NewClient().Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	stream, err := resp.Stream()
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	data, err := io.ReadAll(stream)
+	stream.Close()
+	if err != nil || string(data) != "hello" {
+		t.Fatalf("Stream() read %q, %v; want %q", data, err, "hello")
+	}
+
+	text, err := resp.Text()
+	if err != nil || text != "hello" {
+		t.Errorf("Text() = %q, %v; want %q", text, err, "hello")
+	}
+	raw, err := resp.RawContent()
+	if err != nil || string(raw) != "hello" {
+		t.Errorf("RawContent() = %q, %v; want %q", raw, err, "hello")
+	}
+}
+
+func TestStreamLiveResponseConsumesBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	resp, err := NewClient().Get(server.URL, RequestParams{Stream: true})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	stream, err := resp.Stream()
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	defer stream.Close()
+
+	if _, err := resp.Content(); !errors.Is(err, ErrBodyConsumed) {
+		t.Errorf("Content() error = %v, want %v", err, ErrBodyConsumed)
+	}
+}
+
+// sizedServer 返回 size 字节的响应体，chunked 时不发送 Content-Length，empty 时只声明长度而不发送内容
+func sizedServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+		body := strings.Repeat("x", size)
+		if r.URL.Query().Get("empty") != "" {
+			w.Header().Set("Content-Length", strconv.Itoa(size))
+			return
+		}
+		if r.URL.Query().Get("chunked") == "" {
+			w.Header().Set("Content-Length", strconv.Itoa(size))
+			w.Write([]byte(body))
+			return
+		}
+		// 先刷新头部，迫使服务器使用分块编码
+		w.(http.Flusher).Flush()
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestMaxBodySize(t *testing.T) {
+	server := sizedServer(t)
+	tests := []struct {
+		name    string
+		client  int64
+		request int64
+		query   string
+		wantErr error
+	}{
+		{name: "content-length over limit", client: 100, query: "size=101", wantErr: ErrBodyTooLarge},
+		// 响应体实际为空，只有根据 Content-Length 预先检查才会返回 ErrBodyTooLarge
+		{name: "content-length precheck skips the read", client: 100, query: "size=101&empty=1", wantErr: ErrBodyTooLarge},
+		{name: "chunked eager read", client: 100, query: "size=101&chunked=1", wantErr: ErrBodyTooLarge},
+		{name: "exactly at limit", client: 100, query: "size=100&chunked=1"},
+		{name: "no limit", query: "size=5000"},
+		{name: "request raises the limit", client: 100, request: 5000, query: "size=5000&chunked=1"},
+		{name: "request sets a limit", request: 100, query: "size=101", wantErr: ErrBodyTooLarge},
+		{name: "negative request removes the limit", client: 100, request: -1, query: "size=5000&chunked=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient(WithMaxBodySize(tt.client))
+			resp, err := client.Get(server.URL+"/?"+tt.query, RequestParams{MaxBodySize: tt.request})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Get() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if _, err := resp.Content(); err != nil {
+				t.Errorf("Content() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestMaxBodySizeDecodedContent(t *testing.T) {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write(bytes.Repeat([]byte("x"), 10000))
+	gz.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(compressed.Bytes())
+	}))
+	defer server.Close()
+
+	// 压缩后的数据在限制内，解压后的内容同样受限制
+	resp, err := NewClient(WithMaxBodySize(1000)).Get(server.URL, RequestParams{Headers: map[string]string{"Accept-Encoding": "gzip"}})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if _, err := resp.Content(); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("Content() error = %v, want %v", err, ErrBodyTooLarge)
+	}
+}
+
+func TestStreamMaxBodySize(t *testing.T) {
+	server := sizedServer(t)
+	tests := []struct {
+		name    string
+		query   string
+		bufSize int
+	}{
+		{"small reads", "size=1000&chunked=1", 7},
+		{"large reads", "size=1000&chunked=1", 4096},
+		{"content-length over limit", "size=1000", 4096},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := NewClient(WithMaxBodySize(100)).Get(server.URL+"/?"+tt.query, RequestParams{Stream: true})
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			stream, err := resp.Stream()
+			if err != nil {
+				t.Fatalf("Stream() error = %v", err)
+			}
+			defer stream.Close()
+
+			var got []byte
+			buf := make([]byte, tt.bufSize)
+			for {
+				n, err := stream.Read(buf)
+				got = append(got, buf[:n]...)
+				if err != nil {
+					if !errors.Is(err, ErrBodyTooLarge) {
+						t.Fatalf("Read() error = %v, want %v", err, ErrBodyTooLarge)
+					}
+					break
+				}
+			}
+			if len(got) != 100 {
+				t.Errorf("read %d bytes before ErrBodyTooLarge, want exactly 100", len(got))
+			}
+			if n, err := stream.Read(buf); n != 0 || !errors.Is(err, ErrBodyTooLarge) {
+				t.Errorf("Read() after limit = %d, %v; want 0, %v", n, err, ErrBodyTooLarge)
+			}
+		})
+	}
+}
+
+func TestStreamWithinMaxBodySize(t *testing.T) {
+	server := sizedServer(t)
+	resp, err := NewClient(WithMaxBodySize(100)).Get(server.URL+"/?size=100&chunked=1", RequestParams{Stream: true})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	stream, err := resp.Stream()
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	defer stream.Close()
+	data, err := io.ReadAll(stream)
+	if err != nil || len(data) != 100 {
+		t.Errorf("ReadAll() = %d bytes, %v; want 100 bytes", len(data), err)
+	}
+}
+
+func TestStreamConsumedAndClose(t *testing.T) {
+	server := sizedServer(t)
+	resp, err := NewClient().Get(server.URL+"/?size=10", RequestParams{Stream: true})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	stream, err := resp.Stream()
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+
+	if _, err := resp.RawContent(); !errors.Is(err, ErrBodyConsumed) {
+		t.Errorf("RawContent() error = %v, want %v", err, ErrBodyConsumed)
+	}
+	if _, err := resp.Text(); !errors.Is(err, ErrBodyConsumed) {
+		t.Errorf("Text() error = %v, want %v", err, ErrBodyConsumed)
+	}
+	if _, err := resp.Stream(); !errors.Is(err, ErrBodyConsumed) {
+		t.Errorf("second Stream() error = %v, want %v", err, ErrBodyConsumed)
+	}
+
+	if err := stream.Close(); err != nil {
+		t.Errorf("stream Close() error = %v", err)
+	}
+	if !resp.closed {
+		t.Error("closing the stream did not close the response")
+	}
+	if err := resp.Close(); err != nil {
+		t.Errorf("second Close() error = %v, want nil", err)
+	}
+	if _, err := stream.Read(make([]byte, 1)); err == nil {
+		t.Error("Read() after Close() succeeded")
+	}
+}
+
+func TestCloseBeforeContent(t *testing.T) {
+	server := sizedServer(t)
+	resp, err := NewClient().Get(server.URL+"/?size=10", RequestParams{Stream: true})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if err := resp.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, err := resp.Content(); err == nil {
+		t.Error("Content() after Close() succeeded, want an error")
+	}
+
+	// 非流模式的响应体已在内存中，关闭后仍可读取
+	buffered, err := NewClient().Get(server.URL + "/?size=10")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	buffered.Close()
+	if content, err := buffered.Content(); err != nil || len(content) != 10 {
+		t.Errorf("Content() = %q, %v; want 10 bytes", content, err)
+	}
+}
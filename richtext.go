@@ -0,0 +1,496 @@
+package primp
+
+import (
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/text/width"
+)
+
+// defaultRichTextWidth 是 TextRich 默认的换行宽度
+const defaultRichTextWidth = 80
+
+// richSpan 是一段带有 ANSI 样式的行内文本
+type richSpan struct {
+	text  string
+	style string
+	link  string
+}
+
+// richTextRenderer 将 HTML 文档树渲染为带 ANSI 转义序列的终端文本
+type richTextRenderer struct {
+	base *url.URL
+}
+
+// htmlToRichText 将 HTML 转换为终端富文本，width <= 0 表示不自动换行
+func htmlToRichText(doc *html.Node, baseURL string, width int) string {
+	if width <= 0 {
+		width = math.MaxInt32
+	}
+
+	r := &richTextRenderer{base: documentBaseURL(doc, baseURL)}
+	var lines []string
+	for i, block := range r.blocks(doc, width) {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, block...)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// blocks 将节点的子节点渲染为多个块，每个块由若干行组成
+func (r *richTextRenderer) blocks(n *html.Node, width int) [][]string {
+	var blocks [][]string
+	var spans []richSpan
+
+	flush := func() {
+		if lines := r.wrap(spans, width); len(lines) > 0 {
+			blocks = append(blocks, lines)
+		}
+		spans = nil
+	}
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && (nonRenderedElements[child.DataAtom] || isHiddenElement(child)) {
+			continue
+		}
+		if isBlock(child) {
+			flush()
+			blocks = append(blocks, r.block(child, width)...)
+			continue
+		}
+		spans = append(spans, r.inline(child, "", "")...)
+	}
+	flush()
+
+	return blocks
+}
+
+// block 渲染单个块级元素
+func (r *richTextRenderer) block(n *html.Node, width int) [][]string {
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		style := "1"
+		switch n.DataAtom {
+		case atom.H1:
+			style = "1;4;36"
+		case atom.H2:
+			style = "1;36"
+		}
+		if lines := r.wrap(r.inlineChildren(n, style, ""), width); len(lines) > 0 {
+			return [][]string{lines}
+		}
+		return nil
+	case atom.Hr:
+		return [][]string{{styled(strings.Repeat("─", min(width, defaultRichTextWidth)), "2")}}
+	case atom.Pre:
+		var lines []string
+		for _, line := range strings.Split(strings.TrimSuffix(textContent(n), "\n"), "\n") {
+			lines = append(lines, "  "+styled(strings.ReplaceAll(line, "\t", "    "), "33"))
+		}
+		return [][]string{lines}
+	case atom.Blockquote:
+		var lines []string
+		for i, block := range r.blocks(n, max(width-2, 1)) {
+			if i > 0 {
+				lines = append(lines, "")
+			}
+			lines = append(lines, block...)
+		}
+		if len(lines) == 0 {
+			return nil
+		}
+		for i, line := range lines {
+			lines[i] = styled("│", "2") + " " + line
+		}
+		return [][]string{lines}
+	case atom.Ul, atom.Ol:
+		if lines := r.list(n, width); len(lines) > 0 {
+			return [][]string{lines}
+		}
+		return nil
+	case atom.Table:
+		if lines := r.table(n, width); len(lines) > 0 {
+			return [][]string{lines}
+		}
+		return nil
+	default:
+		return r.blocks(n, width)
+	}
+}
+
+// list 渲染列表，嵌套列表逐级缩进
+func (r *richTextRenderer) list(n *html.Node, width int) []string {
+	ordered := n.DataAtom == atom.Ol
+	index := 1
+	if start, ok := getAttr(n, "start"); ok {
+		if v, err := strconv.Atoi(strings.TrimSpace(start)); err == nil {
+			index = v
+		}
+	}
+
+	var lines []string
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || child.DataAtom != atom.Li || isHiddenElement(child) {
+			continue
+		}
+
+		marker := "• "
+		if ordered {
+			marker = strconv.Itoa(index) + ". "
+			index++
+		}
+		indent := strings.Repeat(" ", len([]rune(marker)))
+
+		var itemLines []string
+		for _, block := range r.blocks(child, max(width-len(indent), 1)) {
+			itemLines = append(itemLines, block...)
+		}
+		if len(itemLines) == 0 {
+			itemLines = []string{""}
+		}
+		for i, line := range itemLines {
+			if i == 0 {
+				lines = append(lines, styled(marker, "2")+line)
+			} else {
+				lines = append(lines, indent+line)
+			}
+		}
+	}
+	return lines
+}
+
+// table 使用制表符框线渲染表格，列宽超出时在单元格内换行
+func (r *richTextRenderer) table(n *html.Node, maxWidth int) []string {
+	var rows [][][]richSpan
+	header := false
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			switch child.DataAtom {
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				walk(child)
+			case atom.Tr:
+				var row [][]richSpan
+				for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type != html.ElementNode || (cell.DataAtom != atom.Td && cell.DataAtom != atom.Th) {
+						continue
+					}
+					style := ""
+					if cell.DataAtom == atom.Th {
+						style = "1"
+						if len(rows) == 0 {
+							header = true
+						}
+					}
+					row = append(row, r.inlineChildren(cell, style, ""))
+				}
+				rows = append(rows, row)
+			}
+		}
+	}
+	walk(n)
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	if columns == 0 {
+		return nil
+	}
+
+	// 计算每列的自然宽度，超出可用宽度时逐步收缩最宽的列
+	widths := make([]int, columns)
+	for _, row := range rows {
+		for i, cell := range row {
+			for _, w := range wrapSpans(cell, math.MaxInt32).widths {
+				widths[i] = max(widths[i], w)
+			}
+		}
+	}
+	available := maxWidth - (3*columns + 1)
+	for {
+		total, widest := 0, 0
+		for i, w := range widths {
+			total += w
+			if w > widths[widest] {
+				widest = i
+			}
+		}
+		if total <= available || widths[widest] <= 3 {
+			break
+		}
+		widths[widest]--
+	}
+
+	border := func(left, middle, right string) string {
+		parts := make([]string, columns)
+		for i, w := range widths {
+			parts[i] = strings.Repeat("─", w+2)
+		}
+		return styled(left+strings.Join(parts, middle)+right, "2")
+	}
+	bar := styled("│", "2")
+
+	lines := []string{border("┌", "┬", "┐")}
+	for rowIndex, row := range rows {
+		cells := make([]wrappedLines, columns)
+		height := 1
+		for i := range cells {
+			if i < len(row) {
+				cells[i] = wrapSpans(row[i], max(widths[i], 1))
+			}
+			height = max(height, len(cells[i].lines))
+		}
+		for line := 0; line < height; line++ {
+			var sb strings.Builder
+			sb.WriteString(bar)
+			for i, cell := range cells {
+				text, w := "", 0
+				if line < len(cell.lines) {
+					text, w = cell.lines[line], cell.widths[line]
+				}
+				sb.WriteString(" " + text + strings.Repeat(" ", max(widths[i]-w, 0)) + " " + bar)
+			}
+			lines = append(lines, sb.String())
+		}
+		if rowIndex == 0 && header && len(rows) > 1 {
+			lines = append(lines, border("├", "┼", "┤"))
+		}
+	}
+	lines = append(lines, border("└", "┴", "┘"))
+	return lines
+}
+
+// inlineChildren 渲染元素的所有子节点为行内文本
+func (r *richTextRenderer) inlineChildren(n *html.Node, style, link string) []richSpan {
+	var spans []richSpan
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		spans = append(spans, r.inline(child, style, link)...)
+	}
+	return spans
+}
+
+// inline 渲染行内节点，style 和 link 由外层元素继承
+func (r *richTextRenderer) inline(n *html.Node, style, link string) []richSpan {
+	switch n.Type {
+	case html.TextNode:
+		return []richSpan{{text: collapseWhitespace(n.Data), style: style, link: link}}
+	case html.ElementNode:
+	default:
+		return nil
+	}
+	if nonRenderedElements[n.DataAtom] || isHiddenElement(n) {
+		return nil
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		return []richSpan{{text: "\n"}}
+	case atom.A:
+		href, _ := getAttr(n, "href")
+		href = stripControl(href)
+		if href != "" && !strings.HasPrefix(strings.ToLower(strings.TrimSpace(href)), "javascript:") {
+			link = resolveURL(r.base, href)
+			style = addStyle(style, "4;34")
+		}
+		return r.inlineChildren(n, style, link)
+	case atom.Img:
+		alt, _ := getAttr(n, "alt")
+		label := "[image]"
+		if alt = strings.TrimSpace(collapseWhitespace(alt)); alt != "" {
+			label = "[image: " + alt + "]"
+		}
+		return []richSpan{{text: label, style: addStyle(style, "2"), link: link}}
+	case atom.Strong, atom.B:
+		return r.inlineChildren(n, addStyle(style, "1"), link)
+	case atom.Em, atom.I:
+		return r.inlineChildren(n, addStyle(style, "3"), link)
+	case atom.U, atom.Ins:
+		return r.inlineChildren(n, addStyle(style, "4"), link)
+	case atom.Del, atom.S, atom.Strike:
+		return r.inlineChildren(n, addStyle(style, "9"), link)
+	case atom.Mark:
+		return r.inlineChildren(n, addStyle(style, "7"), link)
+	case atom.Code, atom.Kbd, atom.Samp:
+		return []richSpan{{text: collapseWhitespace(textContent(n)), style: addStyle(style, "33"), link: link}}
+	default:
+		spans := r.inlineChildren(n, style, link)
+		if isBlock(n) {
+			spans = append(append([]richSpan{{text: " "}}, spans...), richSpan{text: " "})
+		}
+		return spans
+	}
+}
+
+// wrap 按宽度对行内文本换行并返回渲染后的行
+func (r *richTextRenderer) wrap(spans []richSpan, width int) []string {
+	return wrapSpans(spans, width).lines
+}
+
+// wrappedLines 是换行后的渲染结果及每行的可见宽度
+type wrappedLines struct {
+	lines  []string
+	widths []int
+}
+
+// wrapSpans 按单词对带样式的文本换行，过长的单词会被截断到下一行
+func wrapSpans(spans []richSpan, lineWidth int) wrappedLines {
+	var result wrappedLines
+	var line []richSpan
+	current := 0
+	pendingSpace := false
+	var word []richSpan
+	wordWidth := 0
+
+	endLine := func() {
+		result.lines = append(result.lines, renderSpans(line))
+		result.widths = append(result.widths, current)
+		line = nil
+		current = 0
+	}
+
+	placeWord := func() {
+		if len(word) == 0 {
+			return
+		}
+		if current > 0 && pendingSpace && current+1+wordWidth > lineWidth {
+			endLine()
+		} else if current > 0 && pendingSpace {
+			line = appendRune(line, ' ', spaceSpan(line, word))
+			current++
+		}
+		for _, span := range word {
+			for _, ch := range span.text {
+				w := runeWidth(ch)
+				if current > 0 && current+w > lineWidth {
+					endLine()
+				}
+				line = appendRune(line, ch, span)
+				current += w
+			}
+		}
+		word = nil
+		wordWidth = 0
+		pendingSpace = false
+	}
+
+	for _, span := range spans {
+		for _, ch := range span.text {
+			switch ch {
+			case '\n':
+				placeWord()
+				endLine()
+				pendingSpace = false
+			case ' ':
+				placeWord()
+				pendingSpace = current > 0
+			default:
+				word = appendRune(word, ch, span)
+				wordWidth += runeWidth(ch)
+			}
+		}
+	}
+	placeWord()
+	if len(line) > 0 {
+		endLine()
+	}
+
+	// 去掉开头和结尾的空行
+	for len(result.lines) > 0 && result.widths[0] == 0 {
+		result.lines, result.widths = result.lines[1:], result.widths[1:]
+	}
+	for len(result.lines) > 0 && result.widths[len(result.widths)-1] == 0 {
+		result.lines, result.widths = result.lines[:len(result.lines)-1], result.widths[:len(result.widths)-1]
+	}
+	return result
+}
+
+// appendRune 将字符追加到样式相同的最后一段，否则新建一段
+func appendRune(spans []richSpan, ch rune, style richSpan) []richSpan {
+	if n := len(spans); n > 0 && spans[n-1].style == style.style && spans[n-1].link == style.link {
+		spans[n-1].text += string(ch)
+		return spans
+	}
+	return append(spans, richSpan{text: string(ch), style: style.style, link: style.link})
+}
+
+// spaceSpan 当前后文本样式相同时（如链接内的空格），空格沿用该样式
+func spaceSpan(line, word []richSpan) richSpan {
+	if len(line) == 0 || len(word) == 0 {
+		return richSpan{}
+	}
+	prev, next := line[len(line)-1], word[0]
+	if prev.style == next.style && prev.link == next.link {
+		return richSpan{style: prev.style, link: prev.link}
+	}
+	return richSpan{}
+}
+
+// renderSpans 将一行文本渲染为 ANSI 字符串
+func renderSpans(spans []richSpan) string {
+	var sb strings.Builder
+	for i := 0; i < len(spans); i++ {
+		span := spans[i]
+		// 合并同一链接内的连续片段，使 OSC 8 超链接保持完整
+		if span.link != "" {
+			var inner strings.Builder
+			for ; i < len(spans) && spans[i].link == span.link; i++ {
+				inner.WriteString(styled(spans[i].text, spans[i].style))
+			}
+			i--
+			sb.WriteString("\x1b]8;;" + stripControl(span.link) + "\x1b\\" + inner.String() + "\x1b]8;;\x1b\\")
+			continue
+		}
+		sb.WriteString(styled(span.text, span.style))
+	}
+	return sb.String()
+}
+
+// styled 使用 SGR 参数包裹文本，文本中的控制字符会被去掉，避免页面内容向终端注入转义序列
+func styled(text, style string) string {
+	text = stripControl(text)
+	if style == "" || text == "" {
+		return text
+	}
+	return "\x1b[" + style + "m" + text + "\x1b[0m"
+}
+
+// stripControl 去掉 C0、DEL 和 C1 控制字符
+func stripControl(s string) string {
+	return strings.Map(func(ch rune) rune {
+		if ch < 0x20 || (ch >= 0x7f && ch <= 0x9f) {
+			return -1
+		}
+		return ch
+	}, s)
+}
+
+// addStyle 在已有 SGR 参数后追加新的参数
+func addStyle(style, extra string) string {
+	if style == "" {
+		return extra
+	}
+	return style + ";" + extra
+}
+
+// runeWidth 返回字符在终端中占用的列数
+func runeWidth(ch rune) int {
+	switch width.LookupRune(ch).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,171 @@
+package primp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// ansiSequence 匹配 SGR 样式和 OSC 8 超链接转义序列
+var ansiSequence = regexp.MustCompile("\x1b\\[[0-9;]*m|\x1b\\]8;;[^\x1b]*\x1b\\\\")
+
+// richText 将 HTML 片段渲染为富文本
+func richText(t *testing.T, page string, width int) string {
+	t.Helper()
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	return htmlToRichText(doc, "https://example.com/a/b", width)
+}
+
+func TestRichTextStripsControlCharacters(t *testing.T) {
+	page := "<p>before\x1b[2Jafter \u009b31m <a href=\"x\x1b]0;pwn\x07\">link\x07</a></p><pre>code\x1b[2J</pre>"
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	out := htmlToRichText(doc, "https://example.com/", 80)
+
+	for _, injected := range []string{"\x1b[2J", "\x1b]0;", "\x07", "\u009b"} {
+		if strings.Contains(out, injected) {
+			t.Errorf("output contains %q: %q", injected, out)
+		}
+	}
+	if !strings.Contains(out, "before[2Jafter") {
+		t.Errorf("output lost text around the control character: %q", out)
+	}
+	if !strings.Contains(out, "\x1b]8;;https://example.com/x]0;pwn\x1b\\") {
+		t.Errorf("output has no sanitized hyperlink: %q", out)
+	}
+}
+
+func TestRichTextStyles(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want string
+	}{
+		{"h1", "<h1>Title</h1>", "\x1b[1;4;36mTitle\x1b[0m\n"},
+		{"h2", "<h2>Sub</h2>", "\x1b[1;36mSub\x1b[0m\n"},
+		{"h3", "<h3>Small</h3>", "\x1b[1mSmall\x1b[0m\n"},
+		{"emphasis", "<p>a <strong>b</strong> <em>c</em> <code>d</code></p>", "a \x1b[1mb\x1b[0m \x1b[3mc\x1b[0m \x1b[33md\x1b[0m\n"},
+		{"nested styles", "<p><b>bold <i>both</i></b></p>", "\x1b[1mbold\x1b[0m \x1b[1;3mboth\x1b[0m\n"},
+		{"blocks separated", "<h2>A</h2><p>text</p>", "\x1b[1;36mA\x1b[0m\n\ntext\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := richText(t, tt.page, 80); got != tt.want {
+				t.Errorf("htmlToRichText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRichTextHyperlinks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<p><a href="guide.html">Guide</a> <a href="javascript:alert(1)">js</a> <a href="https://other.example/x">abs</a></p>`))
+	}))
+	defer server.Close()
+
+	resp, err := NewClient().Get(server.URL + "/docs/index.html")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	out, err := resp.TextRich()
+	if err != nil {
+		t.Fatalf("TextRich() error = %v", err)
+	}
+
+	want := "\x1b]8;;" + server.URL + "/docs/guide.html\x1b\\\x1b[4;34mGuide\x1b[0m\x1b]8;;\x1b\\"
+	if !strings.Contains(out, want) {
+		t.Errorf("output = %q, want the link resolved against the response URL", out)
+	}
+	if !strings.Contains(out, "\x1b]8;;https://other.example/x\x1b\\") {
+		t.Errorf("output = %q, want the absolute link kept", out)
+	}
+	if strings.Contains(out, "javascript:") || !strings.Contains(out, " js ") {
+		t.Errorf("output = %q, want the javascript: link rendered as plain text", out)
+	}
+}
+
+func TestRichTextTable(t *testing.T) {
+	got := richText(t, "<table><tr><th>Name</th><th>Age</th></tr><tr><td>Alice</td><td>30</td></tr></table>", 80)
+	want := []string{
+		"┌───────┬─────┐",
+		"│ Name  │ Age │",
+		"├───────┼─────┤",
+		"│ Alice │ 30  │",
+		"└───────┴─────┘",
+	}
+	if plain := ansiSequence.ReplaceAllString(got, ""); plain != strings.Join(want, "\n")+"\n" {
+		t.Errorf("table =\n%s\nwant\n%s", plain, strings.Join(want, "\n"))
+	}
+	if !strings.Contains(got, "\x1b[1mName\x1b[0m") {
+		t.Errorf("table = %q, want bold header cells", got)
+	}
+
+	// 超出宽度时在单元格内换行，每行都不超过宽度
+	narrow := richText(t, "<table><tr><td>one two three four five six</td><td>seven eight nine</td></tr></table>", 24)
+	for _, line := range strings.Split(strings.TrimSuffix(narrow, "\n"), "\n") {
+		if w := utf8.RuneCountInString(ansiSequence.ReplaceAllString(line, "")); w > 24 {
+			t.Errorf("table line %q is %d columns wide, want at most 24", line, w)
+		}
+	}
+}
+
+func TestRichTextWrapping(t *testing.T) {
+	words := strings.Repeat("lorem ipsum dolor sit amet ", 12)
+	page := "<p>" + words + "</p>"
+	tests := []struct {
+		name      string
+		width     int
+		wantLines int
+	}{
+		{"default width", defaultRichTextWidth, 4},
+		{"custom width", 30, 12},
+		{"zero disables wrapping", 0, 1},
+		{"negative disables wrapping", -1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := strings.Split(strings.TrimSuffix(richText(t, page, tt.width), "\n"), "\n")
+			if len(lines) != tt.wantLines {
+				t.Errorf("got %d lines, want %d: %q", len(lines), tt.wantLines, lines)
+			}
+			for _, line := range lines {
+				if tt.width > 0 && utf8.RuneCountInString(line) > tt.width {
+					t.Errorf("line %q is longer than %d", line, tt.width)
+				}
+			}
+			if got := strings.Join(lines, " "); got != strings.TrimSpace(words) {
+				t.Errorf("wrapped text lost words: %q", got)
+			}
+		})
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+	resp, err := NewClient().Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defaultOut, _ := resp.TextRich()
+	if want := richText(t, page, defaultRichTextWidth); defaultOut != want {
+		t.Errorf("TextRich() = %q, want the default width of %d", defaultOut, defaultRichTextWidth)
+	}
+	if out, _ := resp.TextRich(0); strings.Count(out, "\n") != 1 {
+		t.Errorf("TextRich(0) = %q, want a single line", out)
+	}
+}
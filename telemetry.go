@@ -0,0 +1,219 @@
+package primp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName 是 OpenTelemetry 的 instrumentation scope 名称
+const instrumentationName = "github.com/stevenwinsirs/go-primp"
+
+// TelemetryOption 是配置 OpenTelemetry 的函数类型
+type TelemetryOption func(*telemetry)
+
+// TelemetryTracerProvider 设置 TracerProvider，默认使用 otel.GetTracerProvider()
+func TelemetryTracerProvider(provider trace.TracerProvider) TelemetryOption {
+	return func(t *telemetry) {
+		t.tracerProvider = provider
+	}
+}
+
+// TelemetryMeterProvider 设置 MeterProvider，默认使用 otel.GetMeterProvider()
+func TelemetryMeterProvider(provider metric.MeterProvider) TelemetryOption {
+	return func(t *telemetry) {
+		t.meterProvider = provider
+	}
+}
+
+// TelemetryPropagator 启用链路上下文传播，请求会带上 propagator 注入的头部（如 traceparent）
+// 默认不注入任何头部，以免破坏浏览器模拟的头部指纹
+func TelemetryPropagator(propagator propagation.TextMapPropagator) TelemetryOption {
+	return func(t *telemetry) {
+		t.propagator = propagator
+	}
+}
+
+// telemetry 保存 tracer 和指标
+type telemetry struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	propagator     propagation.TextMapPropagator
+
+	tracer   trace.Tracer
+	duration metric.Float64Histogram
+	active   metric.Int64UpDownCounter
+	errors   metric.Int64Counter
+}
+
+// newTelemetry 创建 tracer 和指标，创建失败的错误交给 otel.Handle 处理
+func newTelemetry(opts []TelemetryOption) *telemetry {
+	t := &telemetry{}
+	for _, opt := range opts {
+		opt(t)
+	}
+	if t.tracerProvider == nil {
+		t.tracerProvider = otel.GetTracerProvider()
+	}
+	if t.meterProvider == nil {
+		t.meterProvider = otel.GetMeterProvider()
+	}
+
+	t.tracer = t.tracerProvider.Tracer(instrumentationName)
+	meter := t.meterProvider.Meter(instrumentationName)
+	var err error
+	if t.duration, err = meter.Float64Histogram("http.client.request.duration",
+		metric.WithDescription("Duration of HTTP client requests."), metric.WithUnit("s")); err != nil {
+		otel.Handle(err)
+	}
+	if t.active, err = meter.Int64UpDownCounter("http.client.active_requests",
+		metric.WithDescription("Number of active HTTP client requests."), metric.WithUnit("{request}")); err != nil {
+		otel.Handle(err)
+	}
+	if t.errors, err = meter.Int64Counter("http.client.request.errors",
+		metric.WithDescription("Number of HTTP client requests that failed or returned an error status."), metric.WithUnit("{request}")); err != nil {
+		otel.Handle(err)
+	}
+	return t
+}
+
+// roundTrip 返回记录 span 和指标的 RoundTripFunc，位于中间件链最外层
+// span 在响应体关闭时结束，因此耗时包含读取响应体的时间
+// 客户端不会自动重试，http.request.resend_count 记录的是重定向次数
+func (t *telemetry) roundTrip(c *Client, next RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		attrs := []attribute.KeyValue{
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Hostname()),
+		}
+		if port := req.URL.Port(); port != "" {
+			if n, err := strconv.Atoi(port); err == nil {
+				attrs = append(attrs, attribute.Int("server.port", n))
+			}
+		}
+		spanAttrs := append([]attribute.KeyValue{attribute.String("url.full", req.URL.Redacted())}, attrs...)
+		if c.impersonate != "" {
+			spanAttrs = append(spanAttrs, attribute.String("primp.impersonate", string(c.impersonate)))
+		}
+		if proxy := c.proxyFor(req); proxy != "" {
+			spanAttrs = append(spanAttrs, attribute.String("primp.proxy", proxy))
+		}
+
+		ctx, span := t.tracer.Start(req.Context(), req.Method,
+			trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(spanAttrs...))
+		req = req.WithContext(ctx)
+		if t.propagator != nil {
+			t.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+		}
+
+		start := time.Now()
+		activeAttrs := metric.WithAttributes(attrs...)
+		t.active.Add(ctx, 1, activeAttrs)
+
+		resp, err := next(req)
+		if err != nil {
+			errorType := errorType(err)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			span.SetAttributes(attribute.String("error.type", errorType))
+			t.finish(ctx, span, start, activeAttrs, append(attrs, attribute.String("error.type", errorType)))
+			return nil, err
+		}
+
+		attrs = append(attrs,
+			attribute.Int("http.response.status_code", resp.StatusCode),
+			attribute.String("network.protocol.version", strconv.Itoa(resp.ProtoMajor)+"."+strconv.Itoa(resp.ProtoMinor)),
+		)
+		span.SetAttributes(attrs[len(attrs)-2:]...)
+		if redirects := redirectCount(resp); redirects > 0 {
+			span.SetAttributes(attribute.Int("http.request.resend_count", redirects))
+		}
+		if resp.StatusCode >= 400 {
+			errorType := strconv.Itoa(resp.StatusCode)
+			span.SetStatus(codes.Error, "")
+			span.SetAttributes(attribute.String("error.type", errorType))
+			attrs = append(attrs, attribute.String("error.type", errorType))
+		}
+
+		resp.Body = &tracedBody{ReadCloser: resp.Body, end: func(err error) {
+			if err != nil && !errors.Is(err, io.EOF) {
+				span.RecordError(err)
+			}
+			t.finish(ctx, span, start, activeAttrs, attrs)
+		}}
+		return resp, nil
+	}
+}
+
+// finish 结束 span 并记录指标
+func (t *telemetry) finish(ctx context.Context, span trace.Span, start time.Time, activeAttrs metric.MeasurementOption, attrs []attribute.KeyValue) {
+	options := metric.WithAttributes(attrs...)
+	t.duration.Record(ctx, time.Since(start).Seconds(), options)
+	t.active.Add(ctx, -1, activeAttrs)
+	for _, attr := range attrs {
+		if attr.Key == "error.type" {
+			t.errors.Add(ctx, 1, options)
+			break
+		}
+	}
+	span.End()
+}
+
+// errorType 返回错误的类型名称，用于 error.type 属性
+func errorType(err error) string {
+	switch classifyError(err) {
+	case ErrTimeout:
+		return "timeout"
+	case ErrDNS:
+		return "dns"
+	case ErrTLS:
+		return "tls"
+	case ErrProxy:
+		return "proxy"
+	case ErrTooManyRedirects:
+		return "too_many_redirects"
+	case ErrConnectionRefused:
+		return "connection_refused"
+	}
+	if errors.Is(err, context.Canceled) {
+		return "canceled"
+	}
+	return "_OTHER"
+}
+
+// tracedBody 在响应体读取出错或关闭时结束 span
+type tracedBody struct {
+	io.ReadCloser
+	end  func(error)
+	once sync.Once
+	err  error
+}
+
+// Read 实现 io.Reader 接口
+func (b *tracedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) {
+		b.err = err
+	}
+	return n, err
+}
+
+// Close 关闭响应体并结束 span
+func (b *tracedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		b.end(b.err)
+	})
+	return err
+}
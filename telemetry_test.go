@@ -0,0 +1,183 @@
+package primp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTelemetryShortCircuitMiddleware(t *testing.T) {
+	noContent := func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusNoContent}, nil
+		}
+	}
+	client := NewClient(WithTelemetry(), WithMiddleware(noContent))
+
+	resp, err := client.Get("http://example.invalid/")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer resp.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("StatusCode = %d, want %d", resp.StatusCode, http.StatusNoContent)
+	}
+	content, err := resp.Content()
+	if err != nil {
+		t.Fatalf("Content() error = %v", err)
+	}
+	if len(content) != 0 {
+		t.Errorf("Content() = %q, want empty", content)
+	}
+}
+
+func TestTelemetryInMemory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Traceparent", r.Header.Get("Traceparent"))
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "/ok", http.StatusFound)
+		case "/missing":
+			http.NotFound(w, r)
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer server.Close()
+
+	spans := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	reader := sdkmetric.NewManualReader()
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	client := NewClient(WithTelemetry(TelemetryTracerProvider(tracerProvider), TelemetryMeterProvider(meterProvider)))
+
+	resp, err := client.Get(server.URL + "/redirect")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got := resp.httpResp.Header.Get("X-Traceparent"); got != "" {
+		t.Errorf("traceparent sent without propagator: %q", got)
+	}
+	resp.Close()
+	resp, err = client.Get(server.URL + "/missing")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Close()
+
+	ended := spans.Ended()
+	if len(ended) != 2 {
+		t.Fatalf("got %d spans, want 2", len(ended))
+	}
+	redirected := spanAttributes(ended[0])
+	if ended[0].SpanKind() != trace.SpanKindClient || ended[0].Name() != "GET" {
+		t.Errorf("span = %s %s, want client span GET", ended[0].SpanKind(), ended[0].Name())
+	}
+	wantAttrs := map[attribute.Key]attribute.Value{
+		"http.request.method":       attribute.StringValue("GET"),
+		"url.full":                  attribute.StringValue(server.URL + "/redirect"),
+		"server.address":            attribute.StringValue("127.0.0.1"),
+		"http.response.status_code": attribute.IntValue(200),
+		"http.request.resend_count": attribute.IntValue(1),
+		"network.protocol.version":  attribute.StringValue("1.1"),
+	}
+	for key, want := range wantAttrs {
+		if got, ok := redirected[key]; !ok || got != want {
+			t.Errorf("attribute %s = %v, want %v", key, got.Emit(), want.Emit())
+		}
+	}
+	if _, ok := redirected["error.type"]; ok {
+		t.Error("successful span has error.type")
+	}
+	if got := spanAttributes(ended[1])["error.type"]; got != attribute.StringValue("404") {
+		t.Errorf("error.type = %v, want 404", got.Emit())
+	}
+	if ended[1].Status().Code != codes.Error {
+		t.Errorf("status = %v, want Error", ended[1].Status().Code)
+	}
+
+	var data metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &data); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	metrics := make(map[string]metricdata.Aggregation)
+	for _, scope := range data.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+
+	duration, ok := metrics["http.client.request.duration"].(metricdata.Histogram[float64])
+	if !ok {
+		t.Fatal("missing http.client.request.duration histogram")
+	}
+	var count uint64
+	for _, point := range duration.DataPoints {
+		count += point.Count
+		if point.Sum <= 0 {
+			t.Errorf("duration sum = %v, want > 0", point.Sum)
+		}
+	}
+	if count != 2 {
+		t.Errorf("duration count = %d, want 2", count)
+	}
+
+	active, ok := metrics["http.client.active_requests"].(metricdata.Sum[int64])
+	if !ok {
+		t.Fatal("missing http.client.active_requests counter")
+	}
+	for _, point := range active.DataPoints {
+		if point.Value != 0 {
+			t.Errorf("active requests = %d, want 0", point.Value)
+		}
+	}
+
+	errorCount, ok := metrics["http.client.request.errors"].(metricdata.Sum[int64])
+	if !ok {
+		t.Fatal("missing http.client.request.errors counter")
+	}
+	var errorsTotal int64
+	for _, point := range errorCount.DataPoints {
+		errorsTotal += point.Value
+	}
+	if errorsTotal != 1 {
+		t.Errorf("errors = %d, want 1", errorsTotal)
+	}
+}
+
+func TestTelemetryPropagator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Traceparent", r.Header.Get("Traceparent"))
+	}))
+	defer server.Close()
+
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(tracetest.NewSpanRecorder()))
+	client := NewClient(WithTelemetry(TelemetryTracerProvider(tracerProvider), TelemetryPropagator(propagation.TraceContext{})))
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer resp.Close()
+	if got := resp.httpResp.Header.Get("X-Traceparent"); !strings.HasPrefix(got, "00-") {
+		t.Errorf("traceparent = %q, want a W3C trace context header", got)
+	}
+}
+
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}